@@ -0,0 +1,147 @@
+/*
+File Name       : acl.go
+
+Purpose         : Analysis of Cisco ACL address/wildcard pairs. Unlike a subnet mask, an ACL wildcard
+                  does not have to be contiguous: 10.0.1.0 0.0.254.255 matches every odd third octet.
+                  Reports how many addresses an entry matches, its bit pattern, lists or samples the
+                  matching addresses and checks whether a given address matches.
+
+Usage           : sncalc acl [-match IP] [-list] [-limit N] [-sample N] [-seed N] IP WILDCARD
+*/
+
+package main
+
+import (
+	"bufio"
+	"errors"
+	"flag"
+	"fmt"
+	"math/bits"
+	"math/rand"
+	"os"
+	"sort"
+	"strings"
+)
+
+// wildcardBitPattern renders address/wildcard as dotted binary with an "x" for every bit the
+// wildcard ignores, e.g. 00001010.00000000.xxxxxxx1.xxxxxxxx.
+func wildcardBitPattern(address uint32, wildcard uint32) string {
+	var sb strings.Builder
+	for i := ipTotalBitCount - 1; i >= 0; i-- {
+		switch {
+		case wildcard>>i&1 == 1:
+			sb.WriteByte('x')
+		case address>>i&1 == 1:
+			sb.WriteByte('1')
+		default:
+			sb.WriteByte('0')
+		}
+		if i%8 == 0 && i > 0 {
+			sb.WriteByte('.')
+		}
+	}
+	return sb.String()
+}
+
+// wildcardMatches reports whether ip is matched by the address/wildcard pair.
+func wildcardMatches(address uint32, wildcard uint32, ip uint32) bool {
+	return ip&^wildcard == address&^wildcard
+}
+
+// wildcardAddress returns the kth matching address (0 based, in ascending order) by spreading the
+// bits of k over the wildcard bit positions.
+func wildcardAddress(address uint32, wildcard uint32, k uint64) uint32 {
+	result := address &^ wildcard
+	for i := 0; i < ipTotalBitCount && k != 0; i++ {
+		if wildcard>>i&1 == 1 {
+			result |= uint32(k&1) << i
+			k >>= 1
+		}
+	}
+	return result
+}
+
+// aclMode prints the analysis of one address/wildcard pair.
+func aclMode(args []string) error {
+	fs := flag.NewFlagSet("acl", flag.ContinueOnError)
+	match := fs.String("match", "", "check whether this address matches the entry")
+	list := fs.Bool("list", false, "list the matching addresses in ascending order")
+	limit := fs.Uint64("limit", 0, "maximum number of addresses to list (0 = no limit)")
+	sample := fs.Int("sample", 0, "print this many randomly chosen matching addresses")
+	seed := fs.Int64("seed", 1, "random seed for -sample")
+	if err := parseModeFlags(fs, args); err != nil {
+		return err
+	}
+	if fs.NArg() != 2 {
+		return errors.New("ERROR: acl needs an address and a wildcard mask, e.g. 10.0.1.0 0.0.254.255.")
+	}
+
+	address, err := ipToInt(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	wildcard, err := ipToInt(fs.Arg(1))
+	if err != nil {
+		return err
+	}
+
+	wildcardBits := bits.OnesCount32(wildcard)
+	matchCount := uint64(1) << wildcardBits
+
+	fmt.Printf("%-40s: %s\n", "Address", intToIP(address))
+	fmt.Printf("%-40s: %s\n", "Wildcard Mask", intToIP(wildcard))
+	fmt.Printf("%-40s: %s\n", "Bit Pattern", wildcardBitPattern(address, wildcard))
+	if wildcard&(wildcard+1) == 0 {
+		fmt.Printf("%-40s: %s\n", "Contiguous", fmt.Sprintf("Yes   (equivalent to %v/%v)", intToIP(address&^wildcard), ipTotalBitCount-wildcardBits))
+	} else {
+		fmt.Printf("%-40s: %s\n", "Contiguous", "No")
+	}
+	fmt.Printf("%-40s: %s\n", "Matching Addresses", fmt.Sprintf("%v   (2^wildcard bits) => (2^%v)", matchCount, wildcardBits))
+	fmt.Printf("%-40s: %s\n", "Lowest Match", intToIP(address&^wildcard))
+	fmt.Printf("%-40s: %s\n", "Highest Match", intToIP(address|wildcard))
+	if address&wildcard != 0 {
+		fmt.Printf("%-40s: %s\n", "Note", fmt.Sprintf("address bits under the wildcard are ignored, the entry is %v %v", intToIP(address&^wildcard), intToIP(wildcard)))
+	}
+
+	if *match != "" {
+		ip, err := ipToInt(*match)
+		if err != nil {
+			return err
+		}
+		result := "no match"
+		if wildcardMatches(address, wildcard, ip) {
+			result = "match"
+		}
+		fmt.Printf("%-40s: %s\n", intToIP(ip), result)
+	}
+
+	if *list {
+		w := bufio.NewWriter(os.Stdout)
+		fmt.Fprintf(w, "\n")
+		for k := uint64(0); k < matchCount; k++ {
+			if *limit > 0 && k == *limit {
+				break
+			}
+			fmt.Fprintln(w, intToIP(wildcardAddress(address, wildcard, k)))
+		}
+		w.Flush()
+	}
+
+	if *sample > 0 {
+		r := rand.New(rand.NewSource(*seed))
+		picked := make(map[uint64]bool)
+		for len(picked) < *sample && uint64(len(picked)) < matchCount {
+			picked[uint64(r.Int63n(int64(matchCount)))] = true
+		}
+		samples := make([]uint64, 0, len(picked))
+		for k := range picked {
+			samples = append(samples, k)
+		}
+		sort.Slice(samples, func(i, j int) bool { return samples[i] < samples[j] })
+		fmt.Printf("\n")
+		for _, k := range samples {
+			fmt.Printf("%v\n", intToIP(wildcardAddress(address, wildcard, k)))
+		}
+	}
+	return nil
+}
@@ -0,0 +1,227 @@
+/*
+File Name       : aclmin.go
+
+Purpose         : The reverse of the acl mode: given a set of addresses and prefixes, finds a small set
+                  of address/wildcard pairs, non-contiguous wildcards allowed, that matches exactly that
+                  set. Entries that differ in a single fixed bit and have the same wildcard are merged
+                  repeatedly (as in Quine-McCluskey) until nothing merges any more. This usually needs
+                  far fewer entries than CIDR aggregation, but it is a heuristic and not guaranteed to
+                  find the absolute minimum.
+
+Usage           : sncalc aclmin [-acl NAME] [-f FILE] IP|IP/CIDR ...
+                  Addresses are read from the arguments and from FILE ("-" for stdin), one or more
+                  per line, with "#" comments.
+*/
+
+package main
+
+import (
+	"bufio"
+	"errors"
+	"flag"
+	"fmt"
+	"math/bits"
+	"os"
+	"sort"
+	"strings"
+)
+
+// wildcardEntry is one address/wildcard pair; address has all wildcard bits cleared.
+type wildcardEntry struct {
+	address  uint32
+	wildcard uint32
+}
+
+// parseAddressOrCidr reads an address (a /32) or an IP/CIDR as a wildcard entry.
+func parseAddressOrCidr(s string) (wildcardEntry, error) {
+	if !strings.Contains(s, "/") {
+		ip, err := ipToInt(s)
+		if err != nil {
+			return wildcardEntry{}, err
+		}
+		return wildcardEntry{address: ip}, nil
+	}
+	ip, cidr, err := parseCidr(s)
+	if err != nil {
+		return wildcardEntry{}, err
+	}
+	return wildcardEntry{address: ip & cidrToMaskInt(cidr), wildcard: ^cidrToMaskInt(cidr)}, nil
+}
+
+// removeNestedEntries drops prefixes that are covered by another prefix of the list, so that the
+// remaining entries are disjoint. It relies on the input being contiguous prefixes.
+func removeNestedEntries(entries []wildcardEntry) []wildcardEntry {
+	sort.Slice(entries, func(i, j int) bool {
+		if entries[i].address != entries[j].address {
+			return entries[i].address < entries[j].address
+		}
+		return entries[i].wildcard > entries[j].wildcard
+	})
+	kept := make([]wildcardEntry, 0, len(entries))
+	for _, e := range entries {
+		if len(kept) > 0 {
+			last := kept[len(kept)-1]
+			if e.address >= last.address && e.address|e.wildcard <= last.address|last.wildcard {
+				continue
+			}
+		}
+		kept = append(kept, e)
+	}
+	return kept
+}
+
+// mergeWildcardEntries merges disjoint entries pairwise until no pair can be merged. Two entries
+// merge when they share a wildcard and their addresses differ in exactly one bit; the result
+// matches exactly the union of both. With contiguousOnly the merged bit has to extend the wildcard
+// contiguously, which gives plain CIDR aggregation.
+func mergeWildcardEntries(entries []wildcardEntry, contiguousOnly bool) []wildcardEntry {
+	groups := make(map[uint32]map[uint32]bool)
+	for _, e := range entries {
+		if groups[e.wildcard] == nil {
+			groups[e.wildcard] = make(map[uint32]bool)
+		}
+		groups[e.wildcard][e.address] = true
+	}
+
+	for merged := true; merged; {
+		merged = false
+		wildcards := make([]uint32, 0, len(groups))
+		for wildcard := range groups {
+			wildcards = append(wildcards, wildcard)
+		}
+		sort.Slice(wildcards, func(i, j int) bool { return wildcards[i] < wildcards[j] })
+
+		for _, wildcard := range wildcards {
+			group := groups[wildcard]
+			addresses := make([]uint32, 0, len(group))
+			for address := range group {
+				addresses = append(addresses, address)
+			}
+			sort.Slice(addresses, func(i, j int) bool { return addresses[i] < addresses[j] })
+
+			for _, address := range addresses {
+				if !group[address] {
+					continue
+				}
+				for i := 0; i < ipTotalBitCount; i++ {
+					bit := uint32(1) << i
+					if wildcard&bit != 0 {
+						continue
+					}
+					if contiguousOnly && i != bits.OnesCount32(wildcard) {
+						break
+					}
+					partner := address ^ bit
+					if !group[partner] {
+						continue
+					}
+					delete(group, address)
+					delete(group, partner)
+					newWildcard := wildcard | bit
+					if groups[newWildcard] == nil {
+						groups[newWildcard] = make(map[uint32]bool)
+					}
+					groups[newWildcard][address&^bit] = true
+					merged = true
+					break
+				}
+			}
+			if len(group) == 0 {
+				delete(groups, wildcard)
+			}
+		}
+	}
+
+	result := make([]wildcardEntry, 0)
+	for wildcard, group := range groups {
+		for address := range group {
+			result = append(result, wildcardEntry{address: address, wildcard: wildcard})
+		}
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].address != result[j].address {
+			return result[i].address < result[j].address
+		}
+		return result[i].wildcard < result[j].wildcard
+	})
+	return result
+}
+
+// readAddressList collects the whitespace or comma separated items of a list file.
+func readAddressList(path string) ([]string, error) {
+	f := os.Stdin
+	if path != "-" {
+		var err error
+		f, err = os.Open(path)
+		if err != nil {
+			return nil, fmt.Errorf("ERROR: %v", err)
+		}
+		defer f.Close()
+	}
+	var items []string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line, _, _ := strings.Cut(scanner.Text(), "#")
+		items = append(items, strings.FieldsFunc(line, func(r rune) bool {
+			return r == ',' || r == ' ' || r == '\t'
+		})...)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("ERROR: %v", err)
+	}
+	return items, nil
+}
+
+// aclMinMode prints the merged wildcard entries as Cisco permit lines.
+func aclMinMode(args []string) error {
+	fs := flag.NewFlagSet("aclmin", flag.ContinueOnError)
+	aclName := fs.String("acl", "", "prefix each line with \"access-list NAME\"")
+	listFile := fs.String("f", "", "read addresses and prefixes from this file (\"-\" for stdin)")
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage of aclmin: sncalc aclmin [-acl NAME] [-f FILE] IP|IP/CIDR ...\n")
+		fmt.Fprintf(fs.Output(), "Entries are merged heuristically; the result is small but not guaranteed to be minimal.\n")
+		fs.PrintDefaults()
+	}
+	if err := parseModeFlags(fs, args); err != nil {
+		return err
+	}
+
+	items := fs.Args()
+	if *listFile != "" {
+		fileItems, err := readAddressList(*listFile)
+		if err != nil {
+			return err
+		}
+		items = append(items, fileItems...)
+	}
+	if len(items) == 0 {
+		return errors.New("ERROR: aclmin needs at least one address or prefix.")
+	}
+
+	entries := make([]wildcardEntry, 0, len(items))
+	for _, item := range items {
+		e, err := parseAddressOrCidr(item)
+		if err != nil {
+			return err
+		}
+		entries = append(entries, e)
+	}
+	entries = removeNestedEntries(entries)
+
+	cidrEntries := mergeWildcardEntries(entries, true)
+	wildcardEntries := mergeWildcardEntries(cidrEntries, false)
+
+	linePrefix := ""
+	if *aclName != "" {
+		linePrefix = "access-list " + *aclName + " "
+	}
+	total := uint64(0)
+	for _, e := range wildcardEntries {
+		matchCount := uint64(1) << bits.OnesCount32(e.wildcard)
+		total += matchCount
+		line := fmt.Sprintf("%vpermit %v %v", linePrefix, intToIP(e.address), intToIP(e.wildcard))
+		fmt.Printf("%-50v ! %v addresses\n", line, matchCount)
+	}
+	fmt.Printf("! %v entries matching %v addresses (CIDR aggregation needs %v entries; heuristic, not guaranteed minimal)\n", len(wildcardEntries), total, len(cidrEntries))
+	return nil
+}
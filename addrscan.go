@@ -0,0 +1,142 @@
+/*
+File Name       : addrscan.go
+
+Purpose         : Finds the IPv4 and IPv6 addresses in a line of free text such as a log line. A
+                  candidate is a run of hex digits, dots and colons that is not glued to a letter or
+                  digit; it is accepted when it parses as an address. "10.0.0.1:443" yields 10.0.0.1,
+                  "[2001:db8::1]:443" yields 2001:db8::1, a sentence ending in "10.0.0.1." yields
+                  10.0.0.1. Modes working on text, starting with grep, share it so they agree on
+                  what counts as an address.
+*/
+
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"net/netip"
+	"os"
+)
+
+// isAddrByte reports whether c can be part of a textual IPv4 or IPv6 address.
+func isAddrByte(c byte) bool {
+	return c >= '0' && c <= '9' || c >= 'a' && c <= 'f' || c >= 'A' && c <= 'F' || c == '.' || c == ':'
+}
+
+// isWordByte reports whether c is a letter or digit; addresses must not be glued to one.
+func isWordByte(c byte) bool {
+	return c >= '0' && c <= '9' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z'
+}
+
+// scanAddrs calls fn with the position and value of every address in line, left to right, until
+// fn returns false. line[start:end] is the text of the address.
+func scanAddrs(line []byte, fn func(start int, end int, addr netip.Addr) bool) {
+	for i := 0; i < len(line); {
+		if !isAddrByte(line[i]) {
+			if isWordByte(line[i]) {
+				// A word such as "host10"; digits glued to it are not an address.
+				for i < len(line) && isWordByte(line[i]) {
+					i++
+				}
+			} else {
+				i++
+			}
+			continue
+		}
+		end := i
+		for end < len(line) && isAddrByte(line[end]) {
+			end++
+		}
+		if end < len(line) && isWordByte(line[end]) {
+			// Glued to a word, e.g. a hostname like "10.0.0.1.example"; skip the rest of the word.
+			for end < len(line) && isWordByte(line[end]) {
+				end++
+			}
+			i = end
+			continue
+		}
+		// After a word, as in "Foo::Bar" or "host:10.0.0.1", leading separators belong to the word.
+		for i > 0 && isWordByte(line[i-1]) && i < end && (line[i] == ':' || line[i] == '.') {
+			i++
+		}
+		if !scanAddrRun(line, i, end, fn) {
+			return
+		}
+		i = end
+	}
+}
+
+// scanAddrRun handles one candidate run line[start:end]. A run that is not an IPv6 address as a
+// whole may still hold IPv4 addresses separated by colons, as in "10.0.0.1:443" or a timestamp
+// followed by an address. It returns false when fn asked to stop.
+func scanAddrRun(line []byte, start int, end int, fn func(int, int, netip.Addr) bool) bool {
+	run := bytes.TrimRight(line[start:end], ".:")
+	if bytes.Count(run, []byte(":")) >= 2 {
+		if addr, err := netip.ParseAddr(string(run)); err == nil {
+			return fn(start, start+len(run), addr)
+		}
+	}
+	for offset := 0; offset < len(run); {
+		part := run[offset:]
+		if colon := bytes.IndexByte(part, ':'); colon >= 0 {
+			part = part[:colon]
+		}
+		if bytes.Count(part, []byte(".")) == 3 {
+			if addr, err := netip.ParseAddr(string(part)); err == nil && addr.Is4() {
+				if !fn(start+offset, start+offset+len(part), addr) {
+					return false
+				}
+			}
+		}
+		offset += len(part) + 1
+	}
+	return true
+}
+
+// forEachLine calls fn with every line of r, newline included. The slice is only valid during the
+// call. Lines are not limited in length.
+func forEachLine(r io.Reader, fn func(line []byte)) error {
+	reader := bufio.NewReaderSize(r, 1<<16)
+	for {
+		line, err := reader.ReadSlice('\n')
+		if err == bufio.ErrBufferFull {
+			// Lines longer than the buffer are rare; collect them in a separate slice.
+			long := append([]byte(nil), line...)
+			for err == bufio.ErrBufferFull {
+				line, err = reader.ReadSlice('\n')
+				long = append(long, line...)
+			}
+			line = long
+		}
+		if len(line) > 0 {
+			fn(line)
+		}
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("ERROR: %v", err)
+		}
+	}
+}
+
+// forEachInputLine runs forEachLine over the named files in turn, or over stdin without any.
+func forEachInputLine(files []string, fn func(line []byte)) error {
+	if len(files) == 0 {
+		return forEachLine(os.Stdin, fn)
+	}
+	for _, name := range files {
+		f, err := os.Open(name)
+		if err != nil {
+			return fmt.Errorf("ERROR: %v", err)
+		}
+		err = forEachLine(f, fn)
+		f.Close()
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"fmt"
+	"net/netip"
+	"reflect"
+	"testing"
+)
+
+func TestScanAddrs(t *testing.T) {
+	for _, tc := range []struct {
+		line string
+		want []string
+	}{
+		{"connect 10.0.0.1:443 ok", []string{"10.0.0.1@8"}},
+		{"[2001:db8::1]:443", []string{"2001:db8::1@1"}},
+		{"ends in 10.0.0.1.", []string{"10.0.0.1@8"}},
+		{"10.0.0.1/24", []string{"10.0.0.1@0"}},
+		{"1.2.3.4,5.6.7.8", []string{"1.2.3.4@0", "5.6.7.8@8"}},
+		{"12:00:01 10.1.2.3", []string{"10.1.2.3@9"}},
+		{"host:10.0.0.1", []string{"10.0.0.1@5"}},
+		{"fe80::1%eth0", []string{"fe80::1@0"}},
+		{"host10.0.0.1", nil},
+		{"10.0.0.1.example.com", nil},
+		{"10.0.0.1a", nil},
+		{"999.1.1.1", nil},
+		{"Foo::Bar", nil},
+	} {
+		var got []string
+		scanAddrs([]byte(tc.line), func(start int, end int, addr netip.Addr) bool {
+			if text := tc.line[start:end]; text != addr.String() {
+				t.Errorf("%q: text %q does not match the address %v", tc.line, text, addr)
+			}
+			got = append(got, fmt.Sprintf("%v@%v", addr, start))
+			return true
+		})
+		if !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("scanAddrs(%q) = %v, want %v", tc.line, got, tc.want)
+		}
+	}
+}
+
+func TestScanAddrsStop(t *testing.T) {
+	calls := 0
+	scanAddrs([]byte("10.0.0.1 10.0.0.2 10.0.0.3"), func(int, int, netip.Addr) bool {
+		calls++
+		return false
+	})
+	if calls != 1 {
+		t.Errorf("scanAddrs went on after fn returned false: %v calls", calls)
+	}
+}
@@ -0,0 +1,188 @@
+/*
+File Name       : aggregate.go
+
+Purpose         : Incident triage on text: finds the IPv4 and IPv6 addresses in logs, text exports of
+                  captures or netstat dumps and counts them per network, most frequent first. Networks
+                  are either the /-v4 and /-v6 networks of the addresses (their network address as in
+                  the summary) or, with -networks, the most specific entry of a list of known networks
+                  with labels:
+                    10.0.0.0/8        corporate
+                    10.20.0.0/16      datacenter east
+                    2001:db8::/32     lab
+                  Addresses outside the list, and with -top N the networks after the first N, are
+                  counted in the "other" bucket. Every occurrence of an address is counted, with
+                  -unique every distinct address once.
+
+Usage           : sncalc aggregate [-v4 24] [-v6 48] [-networks known.txt] [-top N] [-unique] [FILE...]
+*/
+
+package main
+
+import (
+	"bufio"
+	"errors"
+	"flag"
+	"fmt"
+	"net/netip"
+	"os"
+	"sort"
+	"strings"
+)
+
+// aggregateBucket is one line of the result.
+type aggregateBucket struct {
+	network netip.Prefix
+	label   string
+	count   int64
+}
+
+// loadLabeledNetworks reads a "NETWORK [label ...]" file into a trie.
+func loadLabeledNetworks(path string) (*prefixTrie, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("ERROR: %v", err)
+	}
+	defer f.Close()
+	trie := &prefixTrie{}
+	lineNumber := 0
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		lineNumber++
+		line, _, _ := strings.Cut(scanner.Text(), "#")
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+		prefix, err := parseNetworkArg(fields[0])
+		if err != nil {
+			return nil, fmt.Errorf("%v (%v line %v)", err, path, lineNumber)
+		}
+		trie.insert(prefix, strings.Join(fields[1:], " "))
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("ERROR: %v", err)
+	}
+	return trie, nil
+}
+
+// groupNetwork returns the network of addr for a prefix length of v4Bits or v6Bits. IPv4 uses the
+// same mask arithmetic as the summary.
+func groupNetwork(addr netip.Addr, v4Bits int, v6Bits int) netip.Prefix {
+	addr = addr.Unmap()
+	if addr.Is4() {
+		b := addr.As4()
+		ip := uint32(b[0])<<24 | uint32(b[1])<<16 | uint32(b[2])<<8 | uint32(b[3])
+		network := ip & cidrToMaskInt(v4Bits)
+		return netip.PrefixFrom(netip.AddrFrom4([4]byte{byte(network >> 24), byte(network >> 16), byte(network >> 8), byte(network)}), v4Bits)
+	}
+	prefix, _ := addr.WithZone("").Prefix(v6Bits)
+	return prefix
+}
+
+// aggregateMode counts the addresses of the named files, or stdin, per network.
+func aggregateMode(args []string) error {
+	fs := flag.NewFlagSet("aggregate", flag.ContinueOnError)
+	v4Bits := fs.Int("v4", 24, "prefix length to group IPv4 addresses by")
+	v6Bits := fs.Int("v6", 48, "prefix length to group IPv6 addresses by")
+	networksFile := fs.String("networks", "", "group by the networks of this file (NETWORK [label] per line)")
+	top := fs.Int("top", 0, "show the N largest networks and add the rest to \"other\" (0 = all)")
+	unique := fs.Bool("unique", false, "count every distinct address once")
+	if err := parseModeFlags(fs, args); err != nil {
+		return err
+	}
+	if *v4Bits < 0 || *v4Bits > ipTotalBitCount {
+		return errors.New("ERROR: -v4 must be between 0 and 32.")
+	}
+	if *v6Bits < 0 || *v6Bits > ipv6TotalBitCount {
+		return errors.New("ERROR: -v6 must be between 0 and 128.")
+	}
+	if *top < 0 {
+		return errors.New("ERROR: -top must not be negative.")
+	}
+
+	var known *prefixTrie
+	if *networksFile != "" {
+		var err error
+		known, err = loadLabeledNetworks(*networksFile)
+		if err != nil {
+			return err
+		}
+	}
+
+	buckets := make(map[netip.Prefix]*aggregateBucket)
+	seen := make(map[netip.Addr]bool)
+	var total, other int64
+	err := forEachInputLine(fs.Args(), func(line []byte) {
+		scanAddrs(line, func(start int, end int, addr netip.Addr) bool {
+			addr = addr.Unmap()
+			if *unique {
+				if seen[addr] {
+					return true
+				}
+				seen[addr] = true
+			}
+			total++
+			var network netip.Prefix
+			label := ""
+			if known != nil {
+				var ok bool
+				network, label, ok = known.lookup(addr)
+				if !ok {
+					other++
+					return true
+				}
+			} else {
+				network = groupNetwork(addr, *v4Bits, *v6Bits)
+			}
+			b := buckets[network]
+			if b == nil {
+				b = &aggregateBucket{network: network, label: label}
+				buckets[network] = b
+			}
+			b.count++
+			return true
+		})
+	})
+	if err != nil {
+		return err
+	}
+
+	sorted := make([]*aggregateBucket, 0, len(buckets))
+	for _, b := range buckets {
+		sorted = append(sorted, b)
+	}
+	sort.Slice(sorted, func(i, j int) bool {
+		if sorted[i].count != sorted[j].count {
+			return sorted[i].count > sorted[j].count
+		}
+		if sorted[i].network.Addr().Is4() != sorted[j].network.Addr().Is4() {
+			return sorted[i].network.Addr().Is4()
+		}
+		return sorted[i].network.Addr().Less(sorted[j].network.Addr())
+	})
+	if *top > 0 && len(sorted) > *top {
+		for _, b := range sorted[*top:] {
+			other += b.count
+		}
+		sorted = sorted[:*top]
+	}
+
+	w := bufio.NewWriter(os.Stdout)
+	defer w.Flush()
+	share := func(count int64) float64 {
+		if total == 0 {
+			return 0
+		}
+		return float64(count) * 100 / float64(total)
+	}
+	fmt.Fprintf(w, "%10v  %6v  %-43v %v\n", "Count", "Share", "Network", "Label")
+	for _, b := range sorted {
+		line := fmt.Sprintf("%10v  %5.1f%%  %-43v %v", b.count, share(b.count), b.network, b.label)
+		fmt.Fprintf(w, "%v\n", strings.TrimRight(line, " "))
+	}
+	if other > 0 {
+		fmt.Fprintf(w, "%10v  %5.1f%%  %v\n", other, share(other), "other")
+	}
+	fmt.Fprintf(w, "%10v  %5.1f%%  %v\n", total, share(total), "total")
+	return nil
+}
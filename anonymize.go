@@ -0,0 +1,216 @@
+/*
+File Name       : anonymize.go
+
+Purpose         : Prefix-preserving anonymization of the IPv4 and IPv6 addresses in text, so logs and
+                  configs can be shared without the real addressing. The scheme is Crypto-PAn: bit i
+                  of the output is bit i of the input XORed with a pseudorandom bit derived from the
+                  first i input bits with AES. Two addresses sharing an N-bit prefix therefore share
+                  an N-bit prefix after anonymization, and the subnet structure can still be
+                  analyzed. The same secret always gives the same mapping, so files anonymized
+                  separately stay consistent.
+
+                  The 32-byte Crypto-PAn key (AES key and pad) is the SHA-256 of the secret. With
+                  -keep-special the well-known prefix of private, loopback, link-local, shared,
+                  multicast and unique local addresses (the address types of enrich) is kept, e.g.
+                  10.1.2.3 stays in 10.0.0.0/8, and only the remaining bits are anonymized. Other
+                  addresses never come out in a kept range, so no two addresses collide and a public
+                  address cannot pass for a private one; the rare address whose result had to be
+                  moved out of a kept range or off a mask loses the shared prefix with its neighbors.
+
+                  IPv4 subnet masks and wildcard masks (255.255.255.0, 0.0.0.255) are left as they
+                  are, so anonymized configs stay valid. The few addresses that look like one, such
+                  as 255.255.255.255 or 0.0.0.3, are left too.
+
+Usage           : sncalc anonymize (-secret TEXT | -secret-file FILE) [-keep-special] [FILE...]
+*/
+
+package main
+
+import (
+	"bufio"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/sha256"
+	"errors"
+	"flag"
+	"fmt"
+	"math/bits"
+	"net/netip"
+	"os"
+	"strings"
+)
+
+// keptTypes are the addressTypes kept recognizable with -keep-special.
+var keptTypes = map[string]bool{
+	"this-network": true, "private": true, "shared": true, "loopback": true, "link-local": true,
+	"multicast": true, "broadcast": true, "unspecified": true, "unique-local": true,
+}
+
+// keptRanges returns the ranges of keptTypes.
+func keptRanges() *prefixTrie {
+	kept := &prefixTrie{}
+	for _, t := range addressTypes {
+		if keptTypes[t.name] {
+			kept.insert(t.network, t.name)
+		}
+	}
+	return kept
+}
+
+// cryptoPAnCacheSize bounds the cache; it is cleared when full, as a log rarely has that many
+// distinct addresses and the mapping does not depend on it.
+const cryptoPAnCacheSize = 1 << 16
+
+// cryptoPAn anonymizes addresses with a fixed key.
+type cryptoPAn struct {
+	block cipher.Block
+	pad   [16]byte
+	cache map[netip.Addr]netip.Addr
+}
+
+// newCryptoPAn sets up the cipher from a 32-byte key: the first half is the AES key, the second
+// half encrypted with it is the pad.
+func newCryptoPAn(key [32]byte) (*cryptoPAn, error) {
+	block, err := aes.NewCipher(key[:16])
+	if err != nil {
+		return nil, fmt.Errorf("ERROR: %v", err)
+	}
+	c := &cryptoPAn{block: block, cache: make(map[netip.Addr]netip.Addr)}
+	block.Encrypt(c.pad[:], key[16:])
+	return c, nil
+}
+
+// anonymize returns the anonymized address. IPv4 addresses stay IPv4, IPv6 stay IPv6.
+func (c *cryptoPAn) anonymize(addr netip.Addr) netip.Addr {
+	addr = addr.WithZone("")
+	if anonymized, ok := c.cache[addr]; ok {
+		return anonymized
+	}
+	if len(c.cache) >= cryptoPAnCacheSize {
+		c.cache = make(map[netip.Addr]netip.Addr)
+	}
+	b := addrBytes(addr)
+	var flips, input, output [16]byte
+	for i := 0; i < addr.BitLen(); i++ {
+		// The first i bits of the address followed by the pad.
+		input = c.pad
+		copy(input[:i/8], b[:i/8])
+		if rest := i % 8; rest > 0 {
+			mask := byte(0xff) << (8 - rest)
+			input[i/8] = b[i/8]&mask | c.pad[i/8]&^mask
+		}
+		c.block.Encrypt(output[:], input[:])
+		flips[i/8] |= output[0] >> 7 << (7 - i%8)
+	}
+	for i := range b {
+		b[i] ^= flips[i]
+	}
+	var anonymized netip.Addr
+	if addr.Is4() {
+		anonymized = netip.AddrFrom4([4]byte{b[0], b[1], b[2], b[3]})
+	} else {
+		anonymized = netip.AddrFrom16(b)
+	}
+	c.cache[addr] = anonymized
+	return anonymized
+}
+
+// isMaskOrWildcard reports whether ip is a contiguous subnet mask or wildcard mask.
+func isMaskOrWildcard(ip uint32) bool {
+	return ip == cidrToMaskInt(bits.OnesCount32(ip)) || ^ip == cidrToMaskInt(bits.OnesCount32(^ip))
+}
+
+// isMaskAddr reports whether addr is an IPv4 subnet or wildcard mask, which is left unanonymized.
+func isMaskAddr(addr netip.Addr) bool {
+	if !addr.Is4() {
+		return false
+	}
+	b := addr.As4()
+	return isMaskOrWildcard(uint32(b[0])<<24 | uint32(b[1])<<16 | uint32(b[2])<<8 | uint32(b[3]))
+}
+
+// keptRange returns the range of kept that addr is in, of the same address family.
+func keptRange(addr netip.Addr, kept *prefixTrie) (netip.Prefix, bool) {
+	prefix, _, ok := kept.lookup(addr)
+	return prefix, ok && addr.Is4() == prefix.Addr().Is4()
+}
+
+// anonymizeKeeping anonymizes addr, which must not be a mask, so that the mapping stays one-to-one
+// with the masks left alone and the prefixes of the kept ranges preserved. An address in a kept
+// range keeps the prefix of its range. Any other address is anonymized again while the result is
+// in a kept range or a mask, so it cannot collide with those. This is cycle walking: each step is
+// a permutation (of the range, or of all addresses), so the walk ends at the latest back at addr.
+func (c *cryptoPAn) anonymizeKeeping(addr netip.Addr, kept *prefixTrie) netip.Addr {
+	prefix, inKept := keptRange(addr, kept)
+	next := addr
+	for {
+		next = c.anonymize(next)
+		if inKept {
+			next = keepPrefix(addr, next, prefix.Bits())
+		} else if _, ok := keptRange(next, kept); ok {
+			continue
+		}
+		if !isMaskAddr(next) {
+			return next
+		}
+	}
+}
+
+// keepPrefix replaces the first bits of anonymized by those of original.
+func keepPrefix(original netip.Addr, anonymized netip.Addr, bits int) netip.Addr {
+	o, a := addrBytes(original), addrBytes(anonymized)
+	for i := 0; i < bits; i++ {
+		mask := byte(0x80) >> (i % 8)
+		a[i/8] = a[i/8]&^mask | o[i/8]&mask
+	}
+	if original.Is4() {
+		return netip.AddrFrom4([4]byte{a[0], a[1], a[2], a[3]})
+	}
+	return netip.AddrFrom16(a)
+}
+
+// anonymizeMode rewrites the addresses of the named files, or stdin, to stdout.
+func anonymizeMode(args []string) error {
+	fs := flag.NewFlagSet("anonymize", flag.ContinueOnError)
+	secret := fs.String("secret", "", "secret the mapping is derived from")
+	secretFile := fs.String("secret-file", "", "read the secret from this file")
+	keepSpecial := fs.Bool("keep-special", false, "keep private, loopback, link-local and multicast ranges recognizable")
+	if err := parseModeFlags(fs, args); err != nil {
+		return err
+	}
+	if *secretFile != "" {
+		b, err := os.ReadFile(*secretFile)
+		if err != nil {
+			return fmt.Errorf("ERROR: %v", err)
+		}
+		*secret = strings.TrimRight(string(b), "\r\n")
+	}
+	if *secret == "" {
+		return errors.New("ERROR: anonymize needs -secret or -secret-file.")
+	}
+	c, err := newCryptoPAn(sha256.Sum256([]byte(*secret)))
+	if err != nil {
+		return err
+	}
+
+	special := &prefixTrie{}
+	if *keepSpecial {
+		special = keptRanges()
+	}
+
+	w := bufio.NewWriterSize(os.Stdout, 1<<16)
+	defer w.Flush()
+	return forEachInputLine(fs.Args(), func(line []byte) {
+		written := 0
+		scanAddrs(line, func(start int, end int, addr netip.Addr) bool {
+			if isMaskAddr(addr) {
+				return true
+			}
+			w.Write(line[written:start])
+			w.WriteString(c.anonymizeKeeping(addr, special).String())
+			written = end
+			return true
+		})
+		w.Write(line[written:])
+	})
+}
@@ -0,0 +1,110 @@
+package main
+
+import (
+	"net/netip"
+	"testing"
+)
+
+// TestCryptoPAnReference checks the sample key and addresses of the Crypto-PAn reference
+// implementation.
+func TestCryptoPAnReference(t *testing.T) {
+	key := [32]byte{21, 34, 23, 141, 51, 164, 207, 128, 19, 10, 91, 22, 73, 144, 125, 16,
+		216, 152, 143, 131, 121, 121, 101, 39, 98, 87, 76, 45, 42, 132, 34, 2}
+	c, err := newCryptoPAn(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tc := range []struct{ in, want string }{
+		{"128.11.68.132", "135.242.180.132"},
+		{"129.118.74.4", "134.136.186.123"},
+		{"130.132.252.244", "133.68.164.234"},
+		{"141.223.7.43", "141.167.8.160"},
+		{"141.233.145.108", "141.129.237.235"},
+		{"156.29.3.236", "147.225.12.42"},
+		{"165.247.96.84", "162.9.99.234"},
+		{"166.107.77.190", "160.132.178.185"},
+		{"192.102.249.13", "252.138.62.131"},
+	} {
+		if got := c.anonymize(netip.MustParseAddr(tc.in)).String(); got != tc.want {
+			t.Errorf("anonymize(%v) = %v, want %v", tc.in, got, tc.want)
+		}
+	}
+}
+
+func TestIsMaskOrWildcard(t *testing.T) {
+	for _, tc := range []struct {
+		ip   string
+		want bool
+	}{
+		{"255.255.255.0", true},
+		{"255.255.248.0", true},
+		{"0.0.0.255", true},
+		{"0.0.7.255", true},
+		{"0.0.0.0", true},
+		{"255.255.255.255", true},
+		{"10.1.1.1", false},
+		{"255.0.255.0", false},
+		{"0.0.0.254", false},
+	} {
+		ip, err := ipToInt(tc.ip)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := isMaskOrWildcard(ip); got != tc.want {
+			t.Errorf("isMaskOrWildcard(%v) = %v, want %v", tc.ip, got, tc.want)
+		}
+	}
+}
+
+// TestAnonymizeKeepingOneToOne checks that kept ranges keep their prefix, that no other address
+// comes out in a kept range or as a mask, and that no two addresses collide.
+func TestAnonymizeKeepingOneToOne(t *testing.T) {
+	c, err := newCryptoPAn([32]byte{1, 2, 3})
+	if err != nil {
+		t.Fatal(err)
+	}
+	kept := keptRanges()
+
+	// Dense ranges inside and outside kept ranges, and one address of every other IPv4 /16 so
+	// that the results spread over all of the address space.
+	addrs := make(map[netip.Addr]bool)
+	for _, network := range []string{"0.0.0.0/20", "10.1.0.0/20", "8.8.0.0/20", "224.0.0.0/20", "fe80::/116", "2001:db8::/116"} {
+		prefix := netip.MustParsePrefix(network)
+		for addr := prefix.Addr(); prefix.Contains(addr); addr = addr.Next() {
+			addrs[addr] = true
+		}
+	}
+	for i := 0; i < 1<<16; i++ {
+		addrs[netip.AddrFrom4([4]byte{byte(i >> 8), byte(i), 1, 1})] = true
+	}
+
+	seen := make(map[netip.Addr]netip.Addr)
+	walked := 0
+	for addr := range addrs {
+		if isMaskAddr(addr) {
+			continue
+		}
+		got := c.anonymizeKeeping(addr, kept)
+		if other, ok := seen[got]; ok {
+			t.Fatalf("%v and %v both anonymize to %v", other, addr, got)
+		}
+		seen[got] = addr
+		if isMaskAddr(got) {
+			t.Errorf("%v anonymizes to the mask %v", addr, got)
+		}
+		original, wasKept := keptRange(addr, kept)
+		result, isKept := keptRange(got, kept)
+		if wasKept && !original.Contains(got) {
+			t.Errorf("%v left its range %v: %v", addr, original, got)
+		}
+		if !wasKept && isKept {
+			t.Errorf("%v anonymizes into the kept range %v: %v", addr, result, got)
+		}
+		if !wasKept && got != c.anonymize(addr) {
+			walked++
+		}
+	}
+	if walked == 0 {
+		t.Error("no address had to be moved out of a kept range, the test does not cover it")
+	}
+}
@@ -0,0 +1,184 @@
+/*
+File Name       : arith.go
+
+Purpose         : Address arithmetic. Answers questions like "what is 10.8.7.250 + 20?", "what is
+                  the 10th usable host in 10.8.4.0/22?", "which host number is 10.8.5.3?" and
+                  "what is the next /22 after this one?". Results that would run past 0.0.0.0 or
+                  255.255.255.255 are reported as errors instead of wrapping around.
+
+Usage           : sncalc arith IP + N | IP - N
+                  sncalc arith IP/CIDR host N|first|last
+                  sncalc arith IP/CIDR index IP
+                  sncalc arith IP/CIDR next [N] | IP/CIDR prev [N]
+*/
+
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+const maxIPv4Int int64 = 1<<32 - 1
+
+// addOffset adds offset (which may be negative) to ip and fails when the result leaves the IPv4
+// address space.
+func addOffset(ip uint32, offset int64) (uint32, error) {
+	result := int64(ip) + offset
+	if result > maxIPv4Int || offset > maxIPv4Int {
+		return 0, fmt.Errorf("ERROR: %v + %v overflows past 255.255.255.255.", intToIP(ip), offset)
+	}
+	if result < 0 || offset < -maxIPv4Int {
+		return 0, fmt.Errorf("ERROR: %v - %v underflows past 0.0.0.0.", intToIP(ip), -offset)
+	}
+	return uint32(result), nil
+}
+
+// nthHost returns the nth usable host (1 is the first usable address) of the network.
+func nthHost(networkAddress uint32, cidr int, n int64) (uint32, error) {
+	if cidr > maxNetworkBitsForUsefulHosts {
+		return 0, fmt.Errorf("ERROR: A /%v network has no usable host addresses.", cidr)
+	}
+	usableHosts := int64(1)<<(ipTotalBitCount-cidr) - 2
+	if n < 1 || n > usableHosts {
+		return 0, fmt.Errorf("ERROR: Host number must be between 1 and %v for a /%v network.", usableHosts, cidr)
+	}
+	return networkAddress + uint32(n), nil
+}
+
+// hostIndex is the inverse of nthHost.
+func hostIndex(networkAddress uint32, cidr int, ip uint32) (int64, error) {
+	broadcastAddress := networkAddress | ^cidrToMaskInt(cidr)
+	if cidr > maxNetworkBitsForUsefulHosts || ip <= networkAddress || ip >= broadcastAddress {
+		return 0, fmt.Errorf("ERROR: %v is not a usable host address of %v/%v.", intToIP(ip), intToIP(networkAddress), cidr)
+	}
+	return int64(ip - networkAddress), nil
+}
+
+// adjacentSubnet returns the network address of the subnet count blocks after (or before, when
+// count is negative) the given network of the same size.
+func adjacentSubnet(networkAddress uint32, cidr int, count int64) (uint32, error) {
+	blockSize := int64(1) << (ipTotalBitCount - cidr)
+	// There are (maxIPv4Int+1)/blockSize networks of the size in all, so a larger count is out
+	// of range in either direction; checking it first keeps count*blockSize from overflowing.
+	if count > (maxIPv4Int+1)/blockSize {
+		return 0, fmt.Errorf("ERROR: There is no /%v network %v after %v/%v.", cidr, count, intToIP(networkAddress), cidr)
+	}
+	if count < -(maxIPv4Int+1)/blockSize {
+		return 0, fmt.Errorf("ERROR: There is no /%v network %v before %v/%v.", cidr, -count, intToIP(networkAddress), cidr)
+	}
+	result := int64(networkAddress) + count*blockSize
+	if result+blockSize-1 > maxIPv4Int {
+		return 0, fmt.Errorf("ERROR: There is no /%v network %v after %v/%v.", cidr, count, intToIP(networkAddress), cidr)
+	}
+	if result < 0 {
+		return 0, fmt.Errorf("ERROR: There is no /%v network %v before %v/%v.", cidr, -count, intToIP(networkAddress), cidr)
+	}
+	return uint32(result), nil
+}
+
+// arithMode evaluates one arithmetic expression and prints the result.
+func arithMode(args []string) error {
+	fs := flag.NewFlagSet("arith", flag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage of arith:\n  sncalc arith IP + N | IP - N\n  sncalc arith IP/CIDR host N|first|last\n  sncalc arith IP/CIDR index IP\n  sncalc arith IP/CIDR next [N] | IP/CIDR prev [N]\n")
+	}
+	if err := parseModeFlags(fs, args); err != nil {
+		return err
+	}
+	args = fs.Args()
+	if len(args) < 2 {
+		return errors.New("ERROR: arith needs an expression, e.g. \"10.8.7.250 + 20\" or \"10.8.4.0/22 host 10\".")
+	}
+
+	if !strings.Contains(args[0], "/") {
+		ip, err := ipToInt(args[0])
+		if err != nil {
+			return err
+		}
+		if len(args) != 3 || (args[1] != "+" && args[1] != "-") {
+			return errors.New("ERROR: Address arithmetic is written as \"IP + N\" or \"IP - N\".")
+		}
+		offset, err := strconv.ParseInt(args[2], 10, 64)
+		if err != nil {
+			return fmt.Errorf("ERROR: %q is not a valid offset.", args[2])
+		}
+		if args[1] == "-" {
+			offset = -offset
+		}
+		result, err := addOffset(ip, offset)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("%v\n", intToIP(result))
+		return nil
+	}
+
+	ip, cidr, err := parseCidr(args[0])
+	if err != nil {
+		return err
+	}
+	networkAddress := ip & cidrToMaskInt(cidr)
+
+	switch args[1] {
+	case "host":
+		if len(args) != 3 {
+			return errors.New("ERROR: host needs a host number, \"first\" or \"last\".")
+		}
+		var n int64
+		switch args[2] {
+		case "first":
+			n = 1
+		case "last":
+			n = int64(1)<<(ipTotalBitCount-cidr) - 2
+		default:
+			n, err = strconv.ParseInt(args[2], 10, 64)
+			if err != nil {
+				return fmt.Errorf("ERROR: %q is not a valid host number.", args[2])
+			}
+		}
+		host, err := nthHost(networkAddress, cidr, n)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("%v\n", intToIP(host))
+
+	case "index":
+		if len(args) != 3 {
+			return errors.New("ERROR: index needs an IP address.")
+		}
+		hostIP, err := ipToInt(args[2])
+		if err != nil {
+			return err
+		}
+		index, err := hostIndex(networkAddress, cidr, hostIP)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("%v\n", index)
+
+	case "next", "prev":
+		count := int64(1)
+		if len(args) == 3 {
+			count, err = strconv.ParseInt(args[2], 10, 64)
+			if err != nil || count < 0 {
+				return fmt.Errorf("ERROR: %q is not a valid subnet count.", args[2])
+			}
+		}
+		if args[1] == "prev" {
+			count = -count
+		}
+		subnet, err := adjacentSubnet(networkAddress, cidr, count)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("%v/%v\n", intToIP(subnet), cidr)
+
+	default:
+		return fmt.Errorf("ERROR: Unknown operation %q, expected host, index, next or prev.", args[1])
+	}
+	return nil
+}
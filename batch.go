@@ -0,0 +1,193 @@
+/*
+File Name       : batch.go
+
+Purpose         : Results for many networks at once, e.g. the prefixes of an inventory export. Every
+                  line of the input holds one IP/CIDR (a bare address is taken as a /32), "#" starts a
+                  comment. Lines are calculated independently: a bad line is reported on stderr with
+                  its line number and the run continues with the next one. The output has one record
+                  per input line in the format chosen with --output or --format:
+                    text   the summary of every network, one after the other
+                    json   JSON lines, one object per line with the line number and input added
+                    csv    one row per input with the summary columns
+                    tsv    as csv, tab separated
+                    yaml   one document per input, separated by "---"
+                  In json, csv, tsv and yaml a failed line is still a record, with the error filled in.
+                  Without -f, stdin is read when it is not a terminal and neither an IP argument nor
+                  --explain or --bits is given.
+
+Usage           : sncalc -f list.txt [--output json|csv|tsv|yaml] [--format FORMAT [--rows]]
+                  inventory-export | sncalc --output csv
+*/
+
+package main
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// batchRecord is the result of one input line; calcResult is nil when the line failed.
+type batchRecord struct {
+	Line  int    `json:"line"`
+	Input string `json:"input"`
+	Error string `json:"error,omitempty"`
+	*calcResult
+}
+
+// batchHeader holds the csv/tsv columns and yaml keys of a record.
+var batchHeader = []string{
+	"line", "input", "ip_address", "network_address", "first_usable", "last_usable",
+	"broadcast_address", "prefix_length", "subnet_mask", "wildcard_mask",
+	"total_hosts", "usable_hosts", "error",
+}
+
+// batchValues returns the columns of rec in batchHeader order.
+func batchValues(rec batchRecord) []string {
+	r := rec.calcResult
+	if r == nil {
+		r = &calcResult{}
+	}
+	values := []string{
+		strconv.Itoa(rec.Line), rec.Input, r.IPAddress, r.NetworkAddress, r.FirstUsable, r.LastUsable,
+		r.BroadcastAddress, "", r.SubnetMask, r.WildcardMask, "", "", rec.Error,
+	}
+	if rec.calcResult != nil {
+		values[7] = strconv.Itoa(r.PrefixLength)
+		values[10] = strconv.FormatInt(r.TotalHosts, 10)
+		values[11] = strconv.FormatInt(r.UsableHosts, 10)
+	}
+	return values
+}
+
+// calculateBatchInput calculates one input line; a bare address is a /32.
+func calculateBatchInput(input string) (*calcResult, error) {
+	if !strings.Contains(input, "/") {
+		input += "/32"
+	}
+	ip, inputCidr, err := parseCidr(input)
+	if err != nil {
+		return nil, err
+	}
+	return calculate(intToIP(ip), inputCidr)
+}
+
+// stdinIsPiped reports whether stdin is a pipe or a file rather than a terminal or /dev/null.
+func stdinIsPiped() bool {
+	info, err := os.Stdin.Stat()
+	if err != nil {
+		return false
+	}
+	return info.Mode()&(os.ModeNamedPipe|os.ModeCharDevice) == os.ModeNamedPipe || info.Mode().IsRegular()
+}
+
+// runBatch calculates every line of path ("-" for stdin) and writes the records to w. Errors of
+// single lines go to errOut; the returned error reports how many lines failed.
+func runBatch(w io.Writer, errOut io.Writer, path string, output string, format string, rows bool) error {
+	if format == "" {
+		switch output {
+		case "text", "json", "csv", "tsv", "yaml":
+		default:
+			return fmt.Errorf("ERROR: Unknown output format %q, expected text, %v.", output, outputFormats)
+		}
+	} else if _, err := parseFormat(format); err != nil {
+		return err
+	}
+
+	f := os.Stdin
+	if path != "-" {
+		var err error
+		f, err = os.Open(path)
+		if err != nil {
+			return fmt.Errorf("ERROR: %v", err)
+		}
+		defer f.Close()
+	}
+
+	bw := bufio.NewWriter(w)
+	defer bw.Flush()
+	var cw *csv.Writer
+	if format == "" && (output == "csv" || output == "tsv") {
+		cw = csv.NewWriter(bw)
+		if output == "tsv" {
+			cw.Comma = '\t'
+		}
+		cw.Write(batchHeader)
+		defer cw.Flush()
+	}
+
+	lineNumber, records, failed := 0, 0, 0
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		lineNumber++
+		line, _, _ := strings.Cut(scanner.Text(), "#")
+		input := strings.TrimSpace(line)
+		if input == "" {
+			continue
+		}
+		records++
+
+		rec := batchRecord{Line: lineNumber, Input: input}
+		result, err := calculateBatchInput(input)
+		if err == nil && format != "" {
+			err = writeFormatted(bw, format, rows, result)
+		}
+		if err != nil {
+			failed++
+			rec.Error = err.Error()
+			if cw != nil {
+				cw.Flush()
+			}
+			bw.Flush()
+			fmt.Fprintf(errOut, "line %v: %v\n", lineNumber, err)
+		} else {
+			rec.calcResult = result
+		}
+		if format != "" {
+			continue
+		}
+
+		switch output {
+		case "text":
+			if result != nil {
+				fmt.Fprintf(bw, "# line %v: %v\n", lineNumber, input)
+				writeTextResult(bw, result)
+			}
+		case "json":
+			b, err := json.Marshal(rec)
+			if err != nil {
+				return fmt.Errorf("ERROR: %v", err)
+			}
+			fmt.Fprintf(bw, "%s\n", b)
+		case "csv", "tsv":
+			cw.Write(batchValues(rec))
+		case "yaml":
+			fmt.Fprintf(bw, "---\n")
+			for i, value := range batchValues(rec) {
+				if value == "" || strings.ContainsAny(value, ":#\"'") {
+					value = strconv.Quote(value)
+				}
+				fmt.Fprintf(bw, "%v: %v\n", batchHeader[i], value)
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("ERROR: %v", err)
+	}
+	if cw != nil {
+		cw.Flush()
+		if err := cw.Error(); err != nil {
+			return fmt.Errorf("ERROR: %v", err)
+		}
+	}
+	if failed > 0 {
+		bw.Flush()
+		return fmt.Errorf("ERROR: %v of %v lines failed.", failed, records)
+	}
+	return nil
+}
@@ -0,0 +1,90 @@
+/*
+File Name       : bitmap.go
+
+Purpose         : Aligned binary view for --bits. The IP address, subnet mask, wildcard mask, network
+                  and broadcast address are stacked in binary with a "|" exactly at the prefix boundary,
+                  inside an octet if needed. When stdout is a terminal network bits and host bits are
+                  colored differently; otherwise (or with NO_COLOR set) the plain layout is printed.
+*/
+
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+const (
+	colorNetworkBits string = "\033[36m"
+	colorHostBits    string = "\033[33m"
+	colorReset       string = "\033[0m"
+)
+
+// stdoutIsTerminal reports whether colors should be used on stdout.
+func stdoutIsTerminal() bool {
+	if os.Getenv("NO_COLOR") != "" {
+		return false
+	}
+	fi, err := os.Stdout.Stat()
+	return err == nil && fi.Mode()&os.ModeCharDevice != 0
+}
+
+// bitMapLine renders n as dotted binary with "|" at the prefix boundary. The boundary takes the
+// place of the dot when the prefix ends on an octet, so every line has the same width.
+func bitMapLine(n uint32, cidr int, color bool) string {
+	var sb strings.Builder
+	if color {
+		sb.WriteString(colorNetworkBits)
+	}
+	for position := 0; position < ipTotalBitCount; position++ {
+		if position == cidr {
+			sb.WriteByte('|')
+			if color {
+				sb.WriteString(colorHostBits)
+			}
+		} else if position > 0 && position%8 == 0 {
+			sb.WriteByte('.')
+		}
+		sb.WriteByte('0' + byte(n>>(ipTotalBitCount-1-position)&1))
+	}
+	if cidr == ipTotalBitCount {
+		sb.WriteByte('|')
+	}
+	if color {
+		sb.WriteString(colorReset)
+	}
+	return sb.String()
+}
+
+// writeBitMap prints the stacked binary view of ipv4/cidr.
+func writeBitMap(w io.Writer, ipv4 string, cidr int, color bool) error {
+	ip, err := ipToInt(ipv4)
+	if err != nil {
+		return err
+	}
+	mask := cidrToMaskInt(cidr)
+	lines := []struct {
+		label string
+		value uint32
+	}{
+		{"IP Address", ip},
+		{"Subnet Mask", mask},
+		{"Wildcard Mask", ^mask},
+		{"Network Address", ip & mask},
+		{"Broadcast Address", ip | ^mask},
+	}
+
+	// The marker line puts a "v" above the boundary column.
+	boundary := 0
+	if cidr > 0 {
+		boundary = cidr + (cidr-1)/8
+	}
+	fmt.Fprintf(w, "%-20s  %v%v\n", "", strings.Repeat(" ", boundary), "v")
+	for _, line := range lines {
+		fmt.Fprintf(w, "%-20s: %v   %v\n", line.label, bitMapLine(line.value, cidr, color), intToIP(line.value))
+	}
+	fmt.Fprintf(w, "%-20s  %v network bits | %v host bits\n", "", cidr, ipTotalBitCount-cidr)
+	return nil
+}
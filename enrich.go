@@ -0,0 +1,282 @@
+/*
+File Name       : enrich.go
+
+Purpose         : Adds network columns to an existing CSV, e.g. an asset export. The address is read
+                  from the column named with -ip-column, the prefix length from -mask-column ("24",
+                  "/24" or "255.255.255.0") or, without one, from -prefix. The columns
+                    network, prefix, broadcast, first_usable, last_usable, class, type, parent
+                  are appended to every row, where parent is the most specific of the -parents
+                  prefixes containing the address. All other columns are passed through unchanged and
+                  rows are processed one at a time, so the size of the file does not matter. A row
+                  that cannot be calculated is reported on stderr with its line number and written
+                  with the new columns empty, as is a row with more columns than the header.
+
+Usage           : sncalc enrich -ip-column ip [-mask-column mask] [-prefix 32|classful]
+                                [-parents 10.0.0.0/8,172.16.0.0/12 | -parents @file] [-f assets.csv]
+*/
+
+package main
+
+import (
+	"encoding/csv"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"math/bits"
+	"net/netip"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// enrichColumns are the names of the appended columns.
+var enrichColumns = []string{"network", "prefix", "broadcast", "first_usable", "last_usable", "class", "type", "parent"}
+
+// addressTypes are the special purpose IPv4 and IPv6 ranges, most specific first; everything else
+// is public. They are parsed once, as addressType runs for every row. anonymize -keep-special
+// uses the same table.
+var addressTypes = []struct {
+	network netip.Prefix
+	name    string
+}{
+	{netip.MustParsePrefix("255.255.255.255/32"), "broadcast"},
+	{netip.MustParsePrefix("192.0.2.0/24"), "documentation"},
+	{netip.MustParsePrefix("198.51.100.0/24"), "documentation"},
+	{netip.MustParsePrefix("203.0.113.0/24"), "documentation"},
+	{netip.MustParsePrefix("192.88.99.0/24"), "6to4-relay"},
+	{netip.MustParsePrefix("192.0.0.0/24"), "ietf-protocol"},
+	{netip.MustParsePrefix("169.254.0.0/16"), "link-local"},
+	{netip.MustParsePrefix("192.168.0.0/16"), "private"},
+	{netip.MustParsePrefix("198.18.0.0/15"), "benchmarking"},
+	{netip.MustParsePrefix("172.16.0.0/12"), "private"},
+	{netip.MustParsePrefix("100.64.0.0/10"), "shared"},
+	{netip.MustParsePrefix("0.0.0.0/8"), "this-network"},
+	{netip.MustParsePrefix("10.0.0.0/8"), "private"},
+	{netip.MustParsePrefix("127.0.0.0/8"), "loopback"},
+	{netip.MustParsePrefix("224.0.0.0/4"), "multicast"},
+	{netip.MustParsePrefix("240.0.0.0/4"), "reserved"},
+	{netip.MustParsePrefix("::/128"), "unspecified"},
+	{netip.MustParsePrefix("::1/128"), "loopback"},
+	{netip.MustParsePrefix("2001:db8::/32"), "documentation"},
+	{netip.MustParsePrefix("fe80::/10"), "link-local"},
+	{netip.MustParsePrefix("fc00::/7"), "unique-local"},
+	{netip.MustParsePrefix("ff00::/8"), "multicast"},
+}
+
+// addressClass returns the classful address class A to E of ip.
+func addressClass(ip uint32) string {
+	switch {
+	case ip>>31 == 0:
+		return "A"
+	case ip>>30 == 0b10:
+		return "B"
+	case ip>>29 == 0b110:
+		return "C"
+	case ip>>28 == 0b1110:
+		return "D"
+	}
+	return "E"
+}
+
+// classfulPrefix returns the prefix length of the classful network of ip; /32 for class D and E.
+func classfulPrefix(ip uint32) int {
+	switch addressClass(ip) {
+	case "A":
+		return 8
+	case "B":
+		return 16
+	case "C":
+		return 24
+	}
+	return ipTotalBitCount
+}
+
+// addressType returns the special purpose range of ip, e.g. "private" or "loopback", or "public".
+func addressType(ip uint32) string {
+	addr := netip.AddrFrom4([4]byte{byte(ip >> 24), byte(ip >> 16), byte(ip >> 8), byte(ip)})
+	for _, t := range addressTypes {
+		if t.network.Contains(addr) {
+			return t.name
+		}
+	}
+	return "public"
+}
+
+// parseMask reads a prefix length given as "24", "/24" or as dotted subnet mask.
+func parseMask(s string) (int, error) {
+	s = strings.TrimPrefix(strings.TrimSpace(s), "/")
+	if strings.Contains(s, ".") {
+		mask, err := ipToInt(s)
+		if err != nil {
+			return 0, err
+		}
+		cidr := bits.OnesCount32(mask)
+		if cidrToMaskInt(cidr) != mask {
+			return 0, fmt.Errorf("ERROR: %q is not a contiguous subnet mask.", s)
+		}
+		return cidr, nil
+	}
+	cidr, err := strconv.Atoi(s)
+	if err != nil || cidr < 0 || cidr > ipTotalBitCount {
+		return 0, fmt.Errorf("ERROR: %q is not a valid CIDR value.", s)
+	}
+	return cidr, nil
+}
+
+// columnIndex returns the position of name in header.
+func columnIndex(header []string, name string) (int, error) {
+	for i, column := range header {
+		if strings.TrimSpace(column) == name {
+			return i, nil
+		}
+	}
+	return 0, fmt.Errorf("ERROR: Column %q not found, the header has %v.", name, strings.Join(header, ", "))
+}
+
+// enrichValues calculates the appended columns for one address.
+func enrichValues(address string, mask string, defaultPrefix int, parents []wildcardEntry) ([]string, error) {
+	ip, err := ipToInt(strings.TrimSpace(address))
+	if err != nil {
+		return nil, err
+	}
+	cidr := defaultPrefix
+	if strings.TrimSpace(mask) != "" {
+		cidr, err = parseMask(mask)
+		if err != nil {
+			return nil, err
+		}
+	} else if defaultPrefix < 0 {
+		cidr = classfulPrefix(ip)
+	}
+
+	row := newSubnetRow(ip&cidrToMaskInt(cidr), cidr)
+	parent := ""
+	parentSize := uint32(0)
+	for _, p := range parents {
+		if ip&^p.wildcard == p.address && (parent == "" || p.wildcard < parentSize) {
+			parent = fmt.Sprintf("%v/%v", intToIP(p.address), ipTotalBitCount-bits.OnesCount32(p.wildcard))
+			parentSize = p.wildcard
+		}
+	}
+	return []string{
+		row.NetworkAddress, strconv.Itoa(cidr), row.BroadcastAddress, row.FirstUsable, row.LastUsable,
+		addressClass(ip), addressType(ip), parent,
+	}, nil
+}
+
+// enrichMode streams the CSV from -f to stdout with the columns appended.
+func enrichMode(args []string) error {
+	fs := flag.NewFlagSet("enrich", flag.ContinueOnError)
+	ipColumn := fs.String("ip-column", "", "name of the column holding the address (required)")
+	maskColumn := fs.String("mask-column", "", "name of the column holding the prefix length or subnet mask")
+	prefix := fs.String("prefix", "32", "prefix length for rows without a mask, or \"classful\"")
+	parentList := fs.String("parents", "", "comma separated parent prefixes, or @file with one per line")
+	listFile := fs.String("f", "-", "CSV file to read (\"-\" for stdin)")
+	if err := parseModeFlags(fs, args); err != nil {
+		return err
+	}
+	if *ipColumn == "" {
+		return errors.New("ERROR: enrich needs -ip-column.")
+	}
+
+	defaultPrefix := -1
+	if *prefix != "classful" {
+		var err error
+		defaultPrefix, err = parseMask(*prefix)
+		if err != nil {
+			return err
+		}
+	}
+
+	var parents []wildcardEntry
+	if *parentList != "" {
+		items := strings.Split(*parentList, ",")
+		if strings.HasPrefix(*parentList, "@") {
+			var err error
+			items, err = readAddressList((*parentList)[1:])
+			if err != nil {
+				return err
+			}
+		}
+		for _, item := range items {
+			e, err := parseAddressOrCidr(strings.TrimSpace(item))
+			if err != nil {
+				return err
+			}
+			parents = append(parents, e)
+		}
+	}
+
+	in := os.Stdin
+	if *listFile != "-" {
+		var err error
+		in, err = os.Open(*listFile)
+		if err != nil {
+			return fmt.Errorf("ERROR: %v", err)
+		}
+		defer in.Close()
+	}
+	r := csv.NewReader(in)
+	r.FieldsPerRecord = -1
+	w := csv.NewWriter(os.Stdout)
+	defer w.Flush()
+
+	header, err := r.Read()
+	if err != nil {
+		return fmt.Errorf("ERROR: Reading the CSV header: %v", err)
+	}
+	ipIndex, err := columnIndex(header, *ipColumn)
+	if err != nil {
+		return err
+	}
+	maskIndex := -1
+	if *maskColumn != "" {
+		if maskIndex, err = columnIndex(header, *maskColumn); err != nil {
+			return err
+		}
+	}
+	w.Write(append(header, enrichColumns...))
+
+	failed := 0
+	for {
+		record, err := r.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("ERROR: %v", err)
+		}
+		line, _ := r.FieldPos(0)
+		// The appended columns have to line up with the header: longer rows are written through
+		// unchanged with empty computed columns, short rows padded.
+		if len(record) > len(header) {
+			failed++
+			fmt.Fprintf(os.Stderr, "line %v: ERROR: %v columns, the header has %v; written without the computed values.\n", line, len(record), len(header))
+			w.Write(append(record, make([]string, len(enrichColumns))...))
+			continue
+		}
+		for len(record) < len(header) {
+			record = append(record, "")
+		}
+		mask := ""
+		if maskIndex >= 0 {
+			mask = record[maskIndex]
+		}
+		values, err := enrichValues(record[ipIndex], mask, defaultPrefix, parents)
+		if err != nil {
+			failed++
+			fmt.Fprintf(os.Stderr, "line %v: %v\n", line, err)
+			values = make([]string, len(enrichColumns))
+		}
+		w.Write(append(record, values...))
+	}
+	w.Flush()
+	if err := w.Error(); err != nil {
+		return fmt.Errorf("ERROR: %v", err)
+	}
+	if failed > 0 {
+		return fmt.Errorf("ERROR: %v rows could not be enriched.", failed)
+	}
+	return nil
+}
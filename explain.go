@@ -0,0 +1,91 @@
+/*
+File Name       : explain.go
+
+Purpose         : "Show your work" output for --explain. Walks through the subnetting method from the
+                  Lammle study guide: find the interesting octet, work out the block size (256 minus the
+                  mask octet, the blockSize of calculate), list the subnets as multiples of the block
+                  size, locate the subnet of the address, derive broadcast and usable range from the next
+                  subnet, and count hosts and subnets from the bits.
+*/
+
+package main
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// writeExplanation prints the step by step derivation for ipv4/cidr.
+func writeExplanation(w io.Writer, ipv4 string, cidr int) error {
+	r, err := calculate(ipv4, cidr)
+	if err != nil {
+		return err
+	}
+	ip := r.Integer
+	mask := cidrToMaskInt(cidr)
+
+	// The interesting octet is the one in which the mask ends; a /24 ends on a boundary, so its
+	// interesting octet is the 4th with mask value 0 and a single subnet of 256.
+	octetPosition := cidr/8 + 1
+	if cidr == ipTotalBitCount {
+		octetPosition = 4
+	}
+	shift := uint(8 * (4 - octetPosition))
+	maskOctet := int(mask >> shift & 0xff)
+	ipOctet := int(ip >> shift & 0xff)
+	blockSize := 256 - maskOctet
+	subnetOctet := ipOctet / blockSize * blockSize
+	ordinals := []string{"", "1st", "2nd", "3rd", "4th"}
+
+	fmt.Fprintf(w, "Step 1 - Subnet mask\n")
+	fmt.Fprintf(w, "  /%v means %v network bits followed by %v host bits:\n", cidr, cidr, ipTotalBitCount-cidr)
+	fmt.Fprintf(w, "  %v = %v\n\n", r.BinarySubnetMask, r.SubnetMask)
+
+	fmt.Fprintf(w, "Step 2 - Interesting octet\n")
+	fmt.Fprintf(w, "  The mask ends in the %v octet, where the mask value is %v.\n", ordinals[octetPosition], maskOctet)
+	fmt.Fprintf(w, "  The address has %v in that octet.\n\n", ipOctet)
+
+	fmt.Fprintf(w, "Step 3 - Block size\n")
+	fmt.Fprintf(w, "  Block size = 256 - mask octet = 256 - %v = %v\n\n", maskOctet, blockSize)
+
+	fmt.Fprintf(w, "Step 4 - Subnets (multiples of the block size)\n")
+	multiples := make([]string, 0)
+	for m := 0; m < 256; m += blockSize {
+		if len(multiples) == 6 && m+blockSize < 256 {
+			multiples = append(multiples, "...")
+			m = 256 - blockSize
+		}
+		multiples = append(multiples, fmt.Sprintf("%v", m))
+	}
+	fmt.Fprintf(w, "  In the %v octet subnets start at %v\n\n", ordinals[octetPosition], strings.Join(multiples, ", "))
+
+	fmt.Fprintf(w, "Step 5 - Locate the subnet\n")
+	fmt.Fprintf(w, "  %v lies between %v and %v, so the subnet starts at %v:\n", ipOctet, subnetOctet, subnetOctet+blockSize, subnetOctet)
+	fmt.Fprintf(w, "  keep the octets before the %v, use %v in it and 0 after it => network address %v\n\n", ordinals[octetPosition], subnetOctet, r.NetworkAddress)
+
+	fmt.Fprintf(w, "Step 6 - Broadcast address and usable range\n")
+	fmt.Fprintf(w, "  The next subnet starts at %v, so the broadcast octet is %v - 1 = %v\n", subnetOctet+blockSize, subnetOctet+blockSize, subnetOctet+blockSize-1)
+	fmt.Fprintf(w, "  and every octet after it is 255 => broadcast address %v\n", r.BroadcastAddress)
+	if r.UsableHosts > 0 {
+		fmt.Fprintf(w, "  Usable hosts are everything in between: %v - %v\n\n", r.FirstUsable, r.LastUsable)
+	} else {
+		fmt.Fprintf(w, "  A /%v leaves no addresses between network and broadcast, so there are no usable hosts.\n\n", cidr)
+	}
+
+	fmt.Fprintf(w, "Step 7 - Counting hosts and subnets\n")
+	fmt.Fprintf(w, "  Host bits = 32 - %v = %v\n", cidr, r.HostBits)
+	fmt.Fprintf(w, "  Total hosts per subnet  = 2^%v = %v\n", r.HostBits, r.TotalHosts)
+	if r.UsableHosts > 0 {
+		fmt.Fprintf(w, "  Usable hosts per subnet = 2^%v - 2 = %v\n", r.HostBits, r.UsableHosts)
+	} else {
+		fmt.Fprintf(w, "  Usable hosts per subnet = 0\n")
+	}
+	if r.SubnetOctet > 0 {
+		subnetBits := cidr - (r.SubnetOctet-1)*8
+		fmt.Fprintf(w, "  Subnet bits in the %v octet = %v, number of subnets = 2^%v = %v\n", ordinals[r.SubnetOctet], subnetBits, subnetBits, r.NumberOfSubnets)
+	} else {
+		fmt.Fprintf(w, "  A /%v has no subnet bits to borrow, so the subnet list is empty.\n", cidr)
+	}
+	return nil
+}
@@ -0,0 +1,108 @@
+/*
+File Name       : format.go
+
+Purpose         : User-defined output with --format. The format is a Go text/template, given inline,
+                  read from a file ("@file.tmpl") or picked by name from formatTemplates. It is
+                  evaluated once against the calculation result, or with --rows once for every row of
+                  the subnet list, e.g.
+                    sncalc --format '{{.Network}}/{{.Prefix}} gw {{.FirstUsable}}' --rows 10.0.0.0/26
+*/
+
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"sort"
+	"strings"
+	"text/template"
+)
+
+// formatTemplates are the built-in named formats. All but "ifconfig" work with --rows as well.
+var formatTemplates = map[string]string{
+	"cidr":     "{{.Network}}/{{.Prefix}}",
+	"gateway":  "{{.Network}}/{{.Prefix}} gw {{.FirstUsable}}",
+	"range":    "{{.FirstUsable}}-{{.LastUsable}}",
+	"hosts":    "{{.Network}}/{{.Prefix}} {{.UsableHosts}} usable hosts",
+	"ifconfig": "{{.IPAddress}} netmask {{.SubnetMask}} broadcast {{.BroadcastAddress}}",
+}
+
+// Network and Prefix are short names for templates, so "{{.Network}}/{{.Prefix}}" works for the
+// result and for subnet rows alike.
+func (r *calcResult) Network() string { return r.NetworkAddress }
+func (r *calcResult) Prefix() int     { return r.PrefixLength }
+func (row subnetRow) Network() string { return row.NetworkAddress }
+func (row subnetRow) Prefix() int     { return row.PrefixLength }
+
+// templateFieldNames lists the fields and methods a template can use on v.
+func templateFieldNames(v interface{}) string {
+	t := reflect.TypeOf(v)
+	names := make([]string, 0)
+	for i := 0; i < t.NumMethod(); i++ {
+		names = append(names, t.Method(i).Name)
+	}
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	for i := 0; i < t.NumField(); i++ {
+		names = append(names, t.Field(i).Name)
+	}
+	sort.Strings(names)
+	return strings.Join(names, ", ")
+}
+
+// parseFormat resolves a --format value into a template.
+func parseFormat(format string) (*template.Template, error) {
+	text := format
+	if named, ok := formatTemplates[format]; ok {
+		text = named
+	} else if strings.HasPrefix(format, "@") {
+		b, err := os.ReadFile(format[1:])
+		if err != nil {
+			return nil, fmt.Errorf("ERROR: %v", err)
+		}
+		text = string(b)
+	}
+	if !strings.HasSuffix(text, "\n") {
+		text += "\n"
+	}
+	tmpl, err := template.New("format").Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("ERROR: %v", err)
+	}
+	return tmpl, nil
+}
+
+// executeFormat runs tmpl against data and explains which fields exist when the template refers to
+// an unknown one.
+func executeFormat(w io.Writer, tmpl *template.Template, data interface{}) error {
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		if strings.Contains(err.Error(), "can't evaluate field") {
+			return fmt.Errorf("ERROR: %v\nAvailable fields: %v", err, templateFieldNames(data))
+		}
+		return fmt.Errorf("ERROR: %v", err)
+	}
+	_, err := w.Write(buf.Bytes())
+	return err
+}
+
+// writeFormatted renders r with the --format template, once or for every subnet row.
+func writeFormatted(w io.Writer, format string, rows bool, r *calcResult) error {
+	tmpl, err := parseFormat(format)
+	if err != nil {
+		return err
+	}
+	if !rows {
+		return executeFormat(w, tmpl, r)
+	}
+	for _, row := range r.Subnets {
+		if err := executeFormat(w, tmpl, row); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,110 @@
+/*
+File Name       : grep.go
+
+Purpose         : grepcidr-style filter: prints the lines of the input that contain an IPv4 or IPv6
+                  address inside one of the given networks. The networks are loaded into a
+                  prefixTrie, so every address costs a walk of at most 32 or 128 nodes however long the
+                  network list is, and lines are streamed, so multi-GB logs need no memory.
+                    -v       print the lines without a matching address
+                    -first   only check the first address of each line
+                    -o       print only the matching addresses, one per line
+                    -file    read the networks from a file (one or more per line, # comments)
+
+Usage           : sncalc grep [-v] [-first] [-o] [-file networks.txt] [NETWORK[,NETWORK...]] [FILE...]
+                  sncalc grep 10.20.0.0/16,192.168.5.0/24 < access.log
+*/
+
+package main
+
+import (
+	"bufio"
+	"errors"
+	"flag"
+	"net/netip"
+	"os"
+	"strings"
+)
+
+// loadNetworkTrie builds the trie from comma separated networks and the items of a list file.
+func loadNetworkTrie(networks string, listFile string) (*prefixTrie, error) {
+	var items []string
+	if networks != "" {
+		items = strings.Split(networks, ",")
+	}
+	if listFile != "" {
+		fileItems, err := readAddressList(listFile)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, fileItems...)
+	}
+	if len(items) == 0 {
+		return nil, errors.New("ERROR: No networks given.")
+	}
+	trie := &prefixTrie{}
+	for _, item := range items {
+		prefix, err := parseNetworkArg(item)
+		if err != nil {
+			return nil, err
+		}
+		trie.insert(prefix, "")
+	}
+	return trie, nil
+}
+
+// grepLine writes line, or its matching addresses with onlyMatching, when it passes the filter.
+func grepLine(w *bufio.Writer, line []byte, trie *prefixTrie, invert bool, first bool, onlyMatching bool) {
+	matched := false
+	scanAddrs(line, func(start int, end int, addr netip.Addr) bool {
+		if _, _, ok := trie.lookup(addr); ok {
+			matched = true
+			if onlyMatching {
+				w.Write(line[start:end])
+				w.WriteByte('\n')
+				return !first
+			}
+			return false
+		}
+		return !first
+	})
+	if !onlyMatching && matched != invert {
+		w.Write(line)
+		if line[len(line)-1] != '\n' {
+			w.WriteByte('\n')
+		}
+	}
+}
+
+// grepMode filters the named files, or stdin without any.
+func grepMode(args []string) error {
+	fs := flag.NewFlagSet("grep", flag.ContinueOnError)
+	invert := fs.Bool("v", false, "print the lines without a matching address")
+	first := fs.Bool("first", false, "only check the first address of each line")
+	onlyMatching := fs.Bool("o", false, "print only the matching addresses")
+	listFile := fs.String("file", "", "read the networks from this file")
+	if err := parseModeFlags(fs, args); err != nil {
+		return err
+	}
+	if *invert && *onlyMatching {
+		return errors.New("ERROR: -v and -o cannot be combined.")
+	}
+
+	files := fs.Args()
+	networks := ""
+	if *listFile == "" {
+		if len(files) == 0 {
+			return errors.New("ERROR: grep needs a network list or -file.")
+		}
+		networks, files = files[0], files[1:]
+	}
+	trie, err := loadNetworkTrie(networks, *listFile)
+	if err != nil {
+		return err
+	}
+
+	w := bufio.NewWriterSize(os.Stdout, 1<<16)
+	defer w.Flush()
+	return forEachInputLine(files, func(line []byte) {
+		grepLine(w, line, trie, *invert, *first, *onlyMatching)
+	})
+}
@@ -0,0 +1,95 @@
+/*
+File Name       : hosts.go
+
+Purpose         : Lists the usable host addresses of a network for provisioning. Addresses are
+                  written one per line as they are generated, so even a /8 is enumerated without
+                  building the whole list in memory.
+
+Usage           : sncalc hosts [-offset N] [-limit N] [-step N] [-from IP] [-to IP] IP/CIDR
+                  -step N prints every Nth usable address, -from/-to restrict the listing to a
+                  sub-range, and -offset/-limit page through the result.
+*/
+
+package main
+
+import (
+	"bufio"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+)
+
+// hostsMode streams the usable addresses of a network, honouring the paging and stride options.
+func hostsMode(args []string) error {
+	fs := flag.NewFlagSet("hosts", flag.ContinueOnError)
+	offset := fs.Uint64("offset", 0, "number of addresses to skip")
+	limit := fs.Uint64("limit", 0, "maximum number of addresses to print (0 = no limit)")
+	step := fs.Uint64("step", 1, "print every Nth usable address")
+	from := fs.String("from", "", "first address of the sub-range to list")
+	to := fs.String("to", "", "last address of the sub-range to list")
+	if err := parseModeFlags(fs, args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		return errors.New("ERROR: hosts needs exactly one IP/CIDR argument.")
+	}
+	if *step == 0 {
+		return errors.New("ERROR: -step must be at least 1.")
+	}
+
+	ip, cidr, err := parseCidr(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	if cidr > maxNetworkBitsForUsefulHosts {
+		return fmt.Errorf("ERROR: A /%v network has no usable host addresses.", cidr)
+	}
+
+	networkAddress := ip & cidrToMaskInt(cidr)
+	broadcastAddress := networkAddress | ^cidrToMaskInt(cidr)
+	first := uint64(networkAddress) + 1
+	last := uint64(broadcastAddress) - 1
+
+	if *from != "" {
+		fromInt, err := ipToInt(*from)
+		if err != nil {
+			return err
+		}
+		if uint64(fromInt) < first || uint64(fromInt) > last {
+			return fmt.Errorf("ERROR: %v is not a usable host address of %v/%v.", *from, intToIP(networkAddress), cidr)
+		}
+		first = uint64(fromInt)
+	}
+	if *to != "" {
+		toInt, err := ipToInt(*to)
+		if err != nil {
+			return err
+		}
+		if uint64(toInt) < first || uint64(toInt) > last {
+			return fmt.Errorf("ERROR: %v is not a usable host address of %v/%v at or after the start of the range.", *to, intToIP(networkAddress), cidr)
+		}
+		last = uint64(toInt)
+	}
+
+	// Checked before multiplying, as offset*step can overflow uint64.
+	if *offset > (last-first) / *step {
+		return fmt.Errorf("ERROR: -offset %v with -step %v is past the last address %v.", *offset, *step, intToIP(uint32(last)))
+	}
+
+	w := bufio.NewWriter(os.Stdout)
+	defer w.Flush()
+
+	printed := uint64(0)
+	for n := first + *offset**step; ; n += *step {
+		if *limit > 0 && printed == *limit {
+			break
+		}
+		fmt.Fprintln(w, intToIP(uint32(n)))
+		printed++
+		if last-n < *step {
+			break
+		}
+	}
+	return nil
+}
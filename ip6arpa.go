@@ -0,0 +1,180 @@
+/*
+File Name       : ip6arpa.go
+
+Purpose         : IPv6 reverse DNS helpers. Builds the ip6.arpa name of an address, expands a prefix
+                  into the ip6.arpa zones it spans (a /50 is not on a nibble boundary and becomes
+                  four /52 zones) and renders PTR records from a naming template.
+
+Usage           : sncalc ip6arpa [-ptr template] prefix|address ...
+                  Template placeholders: {ip} compressed address with ":" replaced by "-",
+                                         {full} expanded address with ":" replaced by "-",
+                                         {hex} the 32 hex digits of the address.
+*/
+
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"net/netip"
+	"strings"
+)
+
+const (
+	ipv6TotalBitCount int    = 128
+	ip6ArpaSuffix     string = "ip6.arpa."
+)
+
+// ip6ArpaNibbles returns the 32 nibbles of addr in reverse order, least significant first,
+// which is the label order of an ip6.arpa name.
+func ip6ArpaNibbles(addr netip.Addr) []string {
+	b := addr.As16()
+	nibbles := make([]string, 0, 32)
+	for i := len(b) - 1; i >= 0; i-- {
+		nibbles = append(nibbles, fmt.Sprintf("%x", b[i]&0x0f), fmt.Sprintf("%x", b[i]>>4))
+	}
+	return nibbles
+}
+
+// ip6ArpaJoin builds a fully qualified ip6.arpa name from reversed nibble labels.
+func ip6ArpaJoin(nibbles []string) string {
+	if len(nibbles) == 0 {
+		return ip6ArpaSuffix
+	}
+	return strings.Join(nibbles, ".") + "." + ip6ArpaSuffix
+}
+
+// ip6ArpaName returns the full ip6.arpa name of addr, e.g. 2001:db8::1 =>
+// 1.0.0.0. ... .8.b.d.0.1.0.0.2.ip6.arpa.
+func ip6ArpaName(addr netip.Addr) string {
+	return ip6ArpaJoin(ip6ArpaNibbles(addr))
+}
+
+// ip6ArpaZoneName returns the ip6.arpa zone of a nibble aligned prefix, e.g. 2001:db8::/32 =>
+// 8.b.d.0.1.0.0.2.ip6.arpa.
+func ip6ArpaZoneName(zone netip.Prefix) string {
+	zoneNibbles := zone.Bits() / 4
+	return ip6ArpaJoin(ip6ArpaNibbles(zone.Addr())[32-zoneNibbles:])
+}
+
+// ip6ArpaZones expands prefix into the ip6.arpa zones it spans. A prefix on a nibble boundary is a
+// single zone; otherwise the prefix is widened to the next nibble boundary and one zone is returned
+// for every value of the remaining 1 to 3 bits of that nibble.
+func ip6ArpaZones(prefix netip.Prefix) []netip.Prefix {
+	prefix = prefix.Masked()
+	bits := prefix.Bits()
+	zoneBits := (bits + 3) / 4 * 4
+	extraBits := zoneBits - bits
+
+	zones := make([]netip.Prefix, 0, 1<<extraBits)
+	base := prefix.Addr().As16()
+	for i := 0; i < 1<<extraBits; i++ {
+		a := base
+		if extraBits > 0 {
+			nibbleIndex := zoneBits/4 - 1
+			if nibbleIndex%2 == 0 {
+				a[nibbleIndex/2] |= byte(i) << 4
+			} else {
+				a[nibbleIndex/2] |= byte(i)
+			}
+		}
+		zones = append(zones, netip.PrefixFrom(netip.AddrFrom16(a), zoneBits))
+	}
+	return zones
+}
+
+// ip6PtrTarget expands the naming template for addr.
+func ip6PtrTarget(template string, addr netip.Addr) string {
+	full := addr.StringExpanded()
+	r := strings.NewReplacer(
+		"{ip}", strings.ReplaceAll(addr.String(), ":", "-"),
+		"{full}", strings.ReplaceAll(full, ":", "-"),
+		"{hex}", strings.ReplaceAll(full, ":", ""),
+	)
+	return r.Replace(template)
+}
+
+// parseIPv6Arg accepts an IPv6 address or prefix. A plain address is returned as a /128.
+func parseIPv6Arg(arg string) (netip.Prefix, error) {
+	var prefix netip.Prefix
+	var err error
+	if strings.Contains(arg, "/") {
+		prefix, err = netip.ParsePrefix(arg)
+	} else {
+		var addr netip.Addr
+		addr, err = netip.ParseAddr(arg)
+		if err == nil {
+			prefix = netip.PrefixFrom(addr, addr.BitLen())
+		}
+	}
+	if err != nil || !prefix.Addr().Is6() || prefix.Addr().Is4In6() {
+		return netip.Prefix{}, fmt.Errorf("ERROR: %q is not a valid IPv6 address or prefix.", arg)
+	}
+	return prefix, nil
+}
+
+// ip6ArpaMode prints ip6.arpa names for addresses and the zones spanned by prefixes. With -ptr,
+// addresses that fall inside one of the given prefixes are listed as PTR records under the
+// $ORIGIN of their zone; other addresses get PTR records with absolute owner names.
+func ip6ArpaMode(args []string) error {
+	fs := flag.NewFlagSet("ip6arpa", flag.ContinueOnError)
+	ptrTemplate := fs.String("ptr", "", "PTR naming template, e.g. host-{ip}.example.net.")
+	if err := parseModeFlags(fs, args); err != nil {
+		return err
+	}
+	if fs.NArg() == 0 {
+		return errors.New("ERROR: ip6arpa needs at least one IPv6 address or prefix.")
+	}
+
+	var prefixes []netip.Prefix
+	var addrs []netip.Addr
+	for _, arg := range fs.Args() {
+		prefix, err := parseIPv6Arg(arg)
+		if err != nil {
+			return err
+		}
+		if prefix.Bits() == ipv6TotalBitCount {
+			addrs = append(addrs, prefix.Addr())
+		} else {
+			prefixes = append(prefixes, prefix.Masked())
+		}
+	}
+
+	placed := make(map[netip.Addr]bool)
+	for _, prefix := range prefixes {
+		zones := ip6ArpaZones(prefix)
+		fmt.Printf("; %v spans %v zone(s) at /%v\n", prefix, len(zones), zones[0].Bits())
+		for _, zone := range zones {
+			fmt.Printf("%v\n", ip6ArpaZoneName(zone))
+		}
+		if *ptrTemplate == "" {
+			fmt.Printf("\n")
+			continue
+		}
+		for _, zone := range zones {
+			fmt.Printf("\n$ORIGIN %v\n", ip6ArpaZoneName(zone))
+			for _, addr := range addrs {
+				if !zone.Contains(addr) {
+					continue
+				}
+				placed[addr] = true
+				owner := strings.Join(ip6ArpaNibbles(addr)[:(ipv6TotalBitCount-zone.Bits())/4], ".")
+				fmt.Printf("%-64v IN PTR %v\n", owner, ip6PtrTarget(*ptrTemplate, addr))
+			}
+		}
+		fmt.Printf("\n")
+	}
+
+	for _, addr := range addrs {
+		if placed[addr] {
+			continue
+		}
+		if *ptrTemplate == "" {
+			fmt.Printf("%-40v %v\n", addr, ip6ArpaName(addr))
+		} else {
+			fmt.Printf("%v IN PTR %v\n", ip6ArpaName(addr), ip6PtrTarget(*ptrTemplate, addr))
+		}
+	}
+	return nil
+}
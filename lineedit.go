@@ -0,0 +1,180 @@
+/*
+File Name       : lineedit.go
+
+Purpose         : Minimal line editor for the repl mode: cursor movement, history on the up/down
+                  arrows, tab completion and Ctrl-D to end input. On anything that is not a terminal
+                  (a pipe, or a platform without raw mode) plain lines are read instead.
+*/
+
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+)
+
+// lineEditor reads lines from stdin.
+type lineEditor struct {
+	in       *bufio.Reader
+	out      io.Writer
+	terminal bool
+	history  []string
+	complete func(word string) []string
+}
+
+// newLineEditor creates an editor on stdin/stdout; complete returns the candidates for the word in
+// front of the cursor.
+func newLineEditor(complete func(word string) []string) *lineEditor {
+	return &lineEditor{
+		in:       bufio.NewReader(os.Stdin),
+		out:      os.Stdout,
+		terminal: isTerminal(int(os.Stdin.Fd())),
+		complete: complete,
+	}
+}
+
+// addHistory remembers line unless it repeats the previous entry.
+func (e *lineEditor) addHistory(line string) {
+	if line == "" || (len(e.history) > 0 && e.history[len(e.history)-1] == line) {
+		return
+	}
+	e.history = append(e.history, line)
+}
+
+// readLine prints prompt and returns the next line without the newline. io.EOF is returned on
+// Ctrl-D at an empty line or at the end of piped input.
+func (e *lineEditor) readLine(prompt string) (string, error) {
+	if !e.terminal {
+		line, err := e.in.ReadString('\n')
+		if err == io.EOF && line != "" {
+			err = nil
+		}
+		return strings.TrimRight(line, "\r\n"), err
+	}
+
+	restore, err := makeRaw(int(os.Stdin.Fd()))
+	if err != nil {
+		e.terminal = false
+		return e.readLine(prompt)
+	}
+	defer restore()
+
+	var line []rune
+	cursor := 0
+	historyIndex := len(e.history)
+	redraw := func() {
+		fmt.Fprintf(e.out, "\r%v%v\033[K", prompt, string(line))
+		if back := len(line) - cursor; back > 0 {
+			fmt.Fprintf(e.out, "\033[%vD", back)
+		}
+	}
+	redraw()
+
+	for {
+		r, _, err := e.in.ReadRune()
+		if err != nil {
+			return "", err
+		}
+		switch r {
+		case '\r', '\n':
+			fmt.Fprintf(e.out, "\n")
+			return string(line), nil
+		case 4: // Ctrl-D
+			if len(line) == 0 {
+				fmt.Fprintf(e.out, "\n")
+				return "", io.EOF
+			}
+			if cursor < len(line) {
+				line = append(line[:cursor], line[cursor+1:]...)
+			}
+		case 3: // Ctrl-C abandons the line
+			fmt.Fprintf(e.out, "^C\n")
+			line, cursor = nil, 0
+		case 127, 8: // Backspace
+			if cursor > 0 {
+				line = append(line[:cursor-1], line[cursor:]...)
+				cursor--
+			}
+		case 1: // Ctrl-A
+			cursor = 0
+		case 5: // Ctrl-E
+			cursor = len(line)
+		case 21: // Ctrl-U
+			line, cursor = line[cursor:], 0
+		case '\t':
+			line, cursor = e.completeWord(prompt, line, cursor)
+		case 27: // escape sequences: arrows and delete
+			if b, _ := e.in.ReadByte(); b != '[' {
+				break
+			}
+			b, _ := e.in.ReadByte()
+			switch b {
+			case 'A', 'B':
+				if b == 'A' && historyIndex > 0 {
+					historyIndex--
+				} else if b == 'B' && historyIndex < len(e.history) {
+					historyIndex++
+				}
+				if historyIndex < len(e.history) {
+					line = []rune(e.history[historyIndex])
+				} else {
+					line = nil
+				}
+				cursor = len(line)
+			case 'C':
+				if cursor < len(line) {
+					cursor++
+				}
+			case 'D':
+				if cursor > 0 {
+					cursor--
+				}
+			case '3':
+				e.in.ReadByte()
+				if cursor < len(line) {
+					line = append(line[:cursor], line[cursor+1:]...)
+				}
+			}
+		default:
+			if r >= ' ' {
+				line = append(line[:cursor], append([]rune{r}, line[cursor:]...)...)
+				cursor++
+			}
+		}
+		redraw()
+	}
+}
+
+// completeWord completes the word in front of the cursor. A single candidate is inserted in full,
+// several candidates are extended to their common prefix and listed.
+func (e *lineEditor) completeWord(prompt string, line []rune, cursor int) ([]rune, int) {
+	start := cursor
+	for start > 0 && line[start-1] != ' ' {
+		start--
+	}
+	word := string(line[start:cursor])
+	candidates := e.complete(word)
+	if len(candidates) == 0 {
+		return line, cursor
+	}
+	sort.Strings(candidates)
+
+	completion := candidates[0]
+	if len(candidates) > 1 {
+		for _, c := range candidates[1:] {
+			for !strings.HasPrefix(c, completion) {
+				completion = completion[:len(completion)-1]
+			}
+		}
+		fmt.Fprintf(e.out, "\n%v\n", strings.Join(candidates, "  "))
+	} else {
+		completion += " "
+	}
+	insert := []rune(completion)[len([]rune(word)):]
+	line = append(line[:cursor], append(insert, line[cursor:]...)...)
+	return line, cursor + len(insert)
+}
@@ -0,0 +1,151 @@
+/*
+File Name       : netmap.go
+
+Purpose         : Planning of 1:1 network NAT (NETMAP) for connectivity with partners whose address
+                  space overlaps ours. The real and the mapped network must have the same prefix
+                  length and be given by their network address. Addresses given as arguments are
+                  translated in whichever direction applies: real to mapped, or mapped back to real.
+                  The rules for iptables NETMAP, Cisco static network NAT and nftables are printed for
+                  the pair; the host offset mapping is the one of renumber.
+
+Usage           : sncalc netmap -real 192.168.1.0/24 -mapped 10.99.1.0/24 [-iface eth0]
+                                [-rules all|iptables|cisco|nftables] [ADDRESS...]
+*/
+
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+)
+
+// alignedNetwork parses a network that has to be given by its network address.
+func alignedNetwork(flagName string, s string) (uint32, int, error) {
+	ip, cidr, err := parseCidr(s)
+	if err != nil {
+		return 0, 0, err
+	}
+	if network := ip & cidrToMaskInt(cidr); network != ip {
+		return 0, 0, fmt.Errorf("ERROR: -%v %v is not aligned to its prefix length, the network is %v/%v.", flagName, s, intToIP(network), cidr)
+	}
+	return ip, cidr, nil
+}
+
+// netmapRules returns the rules of one syntax for translating realNetwork to mapped.
+func netmapRules(syntax string, realNetwork string, mapped string, cidr int, iface string) ([]string, error) {
+	switch syntax {
+	case "iptables":
+		in, out := "", ""
+		if iface != "" {
+			in, out = " -i "+iface, " -o "+iface
+		}
+		return []string{
+			fmt.Sprintf("iptables -t nat -A PREROUTING%v -d %v/%v -j NETMAP --to %v/%v", in, mapped, cidr, realNetwork, cidr),
+			fmt.Sprintf("iptables -t nat -A POSTROUTING%v -s %v/%v -j NETMAP --to %v/%v", out, realNetwork, cidr, mapped, cidr),
+		}, nil
+	case "cisco":
+		return []string{
+			fmt.Sprintf("ip nat inside source static network %v %v /%v", realNetwork, mapped, cidr),
+		}, nil
+	case "nftables":
+		in, out := "", ""
+		if iface != "" {
+			in, out = fmt.Sprintf("iifname %q ", iface), fmt.Sprintf("oifname %q ", iface)
+		}
+		return []string{
+			"table ip netmap {",
+			"    chain prerouting {",
+			"        type nat hook prerouting priority dstnat; policy accept;",
+			fmt.Sprintf("        %vip daddr %v/%v dnat ip prefix to ip daddr map { %v/%v : %v/%v }", in, mapped, cidr, mapped, cidr, realNetwork, cidr),
+			"    }",
+			"    chain postrouting {",
+			"        type nat hook postrouting priority srcnat; policy accept;",
+			fmt.Sprintf("        %vip saddr %v/%v snat ip prefix to ip saddr map { %v/%v : %v/%v }", out, realNetwork, cidr, realNetwork, cidr, mapped, cidr),
+			"    }",
+			"}",
+		}, nil
+	}
+	return nil, fmt.Errorf("ERROR: Unknown rule syntax %q, expected all, iptables, cisco or nftables.", syntax)
+}
+
+// netmapMode prints the translation of the given addresses and the NAT rules.
+func netmapMode(args []string) error {
+	fs := flag.NewFlagSet("netmap", flag.ContinueOnError)
+	realFlag := fs.String("real", "", "network as addressed inside, IP/CIDR")
+	mappedFlag := fs.String("mapped", "", "network as seen by the partner, IP/CIDR")
+	iface := fs.String("iface", "", "restrict the iptables and nftables rules to this interface")
+	rules := fs.String("rules", "all", "rule syntax: all, iptables, cisco or nftables")
+	if err := parseModeFlags(fs, args); err != nil {
+		return err
+	}
+	if *realFlag == "" || *mappedFlag == "" {
+		return errors.New("ERROR: netmap needs -real and -mapped.")
+	}
+	realIP, realCidr, err := alignedNetwork("real", *realFlag)
+	if err != nil {
+		return err
+	}
+	mappedIP, mappedCidr, err := alignedNetwork("mapped", *mappedFlag)
+	if err != nil {
+		return err
+	}
+	if realCidr != mappedCidr {
+		return fmt.Errorf("ERROR: 1:1 NAT needs networks of the same size, got /%v and /%v.", realCidr, mappedCidr)
+	}
+	if realIP == mappedIP {
+		return errors.New("ERROR: The real and the mapped network are the same.")
+	}
+	forward, err := newAddressMapping(*realFlag, *mappedFlag, "")
+	if err != nil {
+		return err
+	}
+	reverse, err := newAddressMapping(*mappedFlag, *realFlag, "")
+	if err != nil {
+		return err
+	}
+
+	syntaxes := []string{*rules}
+	if *rules == "all" {
+		syntaxes = []string{"iptables", "cisco", "nftables"}
+	}
+	ruleLines := make(map[string][]string)
+	for _, syntax := range syntaxes {
+		lines, err := netmapRules(syntax, intToIP(realIP), intToIP(mappedIP), realCidr, *iface)
+		if err != nil {
+			return err
+		}
+		ruleLines[syntax] = lines
+	}
+
+	lastOffset := ^cidrToMaskInt(realCidr)
+	fmt.Printf("%-40s: %v/%v\n", "Real Network", intToIP(realIP), realCidr)
+	fmt.Printf("%-40s: %v/%v\n", "Mapped Network", intToIP(mappedIP), mappedCidr)
+	fmt.Printf("%-40s: %v\n", "Translated Addresses", uint64(lastOffset)+1)
+	fmt.Printf("%-40s: %v - %v => %v - %v\n", "Mapping", intToIP(realIP), intToIP(realIP+lastOffset), intToIP(mappedIP), intToIP(mappedIP+lastOffset))
+
+	if fs.NArg() > 0 {
+		fmt.Printf("\n")
+		for _, arg := range fs.Args() {
+			ip, err := ipToInt(arg)
+			if err != nil {
+				return err
+			}
+			if mapped, ok := forward.mapAddress(ip); ok {
+				fmt.Printf("  %-20v => %-20v (real => mapped)\n", intToIP(ip), intToIP(mapped))
+			} else if realAddress, ok := reverse.mapAddress(ip); ok {
+				fmt.Printf("  %-20v => %-20v (mapped => real)\n", intToIP(ip), intToIP(realAddress))
+			} else {
+				fmt.Printf("  %-20v    not in either network\n", intToIP(ip))
+			}
+		}
+	}
+
+	for _, syntax := range syntaxes {
+		fmt.Printf("\n# %v\n", syntax)
+		for _, line := range ruleLines[syntax] {
+			fmt.Println(line)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,152 @@
+/*
+File Name       : output.go
+
+Purpose         : Renderings of a calcResult. writeTextResult prints the text summary and subnet list
+                  of main(), the repl and batch mode. For --output, JSON carries the whole
+                  result; CSV, TSV and YAML carry the subnet list with one column per value (first and
+                  last usable address separately, the current subnet as its own column) so the list
+                  can be imported into a spreadsheet.
+*/
+
+package main
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// outputFormats lists the --output values besides the text summary.
+const outputFormats string = "json, csv, tsv or yaml"
+
+// subnetRowHeader holds the column names of the subnet list; they match the JSON field names.
+var subnetRowHeader = []string{
+	"network_address", "first_usable", "last_usable", "broadcast_address",
+	"prefix_length", "total_hosts", "usable_hosts", "current",
+}
+
+// subnetRowValues returns the columns of row in subnetRowHeader order.
+func subnetRowValues(row subnetRow) []string {
+	return []string{
+		row.NetworkAddress, row.FirstUsable, row.LastUsable, row.BroadcastAddress,
+		strconv.Itoa(row.PrefixLength),
+		strconv.FormatInt(row.TotalHosts, 10),
+		strconv.FormatInt(row.UsableHosts, 10),
+		strconv.FormatBool(row.Current),
+	}
+}
+
+// writeSubnetTable writes the subnet list as CSV, or as TSV when comma is a tab.
+func writeSubnetTable(w io.Writer, rows []subnetRow, comma rune) error {
+	cw := csv.NewWriter(w)
+	cw.Comma = comma
+	cw.Write(subnetRowHeader)
+	for _, row := range rows {
+		cw.Write(subnetRowValues(row))
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// writeSubnetYAML writes the subnet list as a YAML sequence of mappings. Values are addresses,
+// numbers and booleans, so only empty strings need quoting.
+func writeSubnetYAML(w io.Writer, rows []subnetRow) {
+	if len(rows) == 0 {
+		fmt.Fprintf(w, "[]\n")
+		return
+	}
+	for _, row := range rows {
+		for i, value := range subnetRowValues(row) {
+			if value == "" {
+				value = `""`
+			}
+			itemPrefix := "  "
+			if i == 0 {
+				itemPrefix = "- "
+			}
+			fmt.Fprintf(w, "%v%v: %v\n", itemPrefix, subnetRowHeader[i], value)
+		}
+	}
+}
+
+// writeTextResult prints the summary of r followed by the subnet list.
+func writeTextResult(w io.Writer, r *calcResult) {
+	usableHostIPRange := ""
+	usableHostsPerSubnet := "0"
+	if r.UsableHosts > 0 {
+		usableHostIPRange = fmt.Sprintf("%v - %v", r.FirstUsable, r.LastUsable)
+		usableHostsPerSubnet = fmt.Sprintf("%v   (2^unmasked bits - 2) => (2^%v - 2)", r.UsableHosts, r.HostBits)
+	}
+	lines := [][2]string{
+		{"IP Address", r.IPAddress},
+		{"Network Address", r.NetworkAddress},
+		{"Usable Host IP Range", usableHostIPRange},
+		{"Broadcast Address", r.BroadcastAddress},
+		{"Total Hosts per Subnet", fmt.Sprintf("%v   (2^unmasked bits) => (2^%v)", r.TotalHosts, r.HostBits)},
+		{"Usable Hosts per Subnet", usableHostsPerSubnet},
+		{"Subnet Mask", r.SubnetMask},
+		{"Wildcard Mask", r.WildcardMask},
+		{"Binary Subnet Mask", r.BinarySubnetMask},
+		{"CIDR Notation", fmt.Sprintf("/%v", r.PrefixLength)},
+		{"Binary Octets", r.BinaryOctets},
+		{"Integer", strconv.FormatUint(uint64(r.Integer), 10)},
+		{"Hexadecimal", r.Hexadecimal},
+		{"Little-endian Hex (/proc)", r.LittleEndianHex},
+		{"Octal", r.Octal},
+		{"Network Bits (total masked bits)", strconv.Itoa(r.NetworkBits)},
+		{"Hosts Bits (unmasked bits)", strconv.Itoa(r.HostBits)},
+	}
+	fmt.Fprintf(w, "\n")
+	for _, line := range lines {
+		fmt.Fprintf(w, "%-40s: %s\n", line[0], line[1])
+	}
+	fmt.Fprintf(w, "\n\n")
+
+	if r.SubnetOctet == 0 {
+		fmt.Fprintf(w, "Number of Subnets: 0\n")
+	} else {
+		ordinal := []string{"", "1st", "2nd", "3rd", "4th"}[r.SubnetOctet]
+		subnetBits := r.PrefixLength - (r.SubnetOctet-1)*8
+		fmt.Fprintf(w, "Number of Subnets: %v   (2^masked bits on %v octet) => (2^%v)\n", r.NumberOfSubnets, ordinal, subnetBits)
+		octets := strings.Split(r.IPAddress, ".")
+		if r.SubnetOctet == 1 {
+			fmt.Fprintf(w, "All %v of the Possible /%v Networks (valid subnets at %v octet):\n", r.NumberOfSubnets, r.PrefixLength, ordinal)
+		} else {
+			pattern := strings.Join(octets[:r.SubnetOctet-1], ".") + strings.Repeat(".*", 5-r.SubnetOctet)
+			fmt.Fprintf(w, "All %v of the Possible /%v Networks for %v (valid subnets at %v octet):\n", r.NumberOfSubnets, r.PrefixLength, pattern, ordinal)
+		}
+	}
+	fmt.Fprintf(w, "  %-20v %-40v %v\n", "Network Address", "Usable Host Range", "Broadcast Address")
+	fmt.Fprintf(w, "  %-20v %-40v %v\n", "---------------", "-----------------", "-----------------")
+	for _, row := range r.Subnets {
+		current := ""
+		if row.Current {
+			current = " [current]"
+		}
+		fmt.Fprintf(w, "  %-20v %-40v %v%v\n", row.NetworkAddress, row.FirstUsable+" - "+row.LastUsable, row.BroadcastAddress, current)
+	}
+	fmt.Fprintf(w, "\n")
+}
+
+// writeResult renders r in one of the outputFormats.
+func writeResult(w io.Writer, output string, r *calcResult) error {
+	switch output {
+	case "json":
+		out, err := jsonResult(r)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(w, "%s\n", out)
+	case "csv":
+		return writeSubnetTable(w, r.Subnets, ',')
+	case "tsv":
+		return writeSubnetTable(w, r.Subnets, '\t')
+	case "yaml":
+		writeSubnetYAML(w, r.Subnets)
+	default:
+		return fmt.Errorf("ERROR: Unknown output format %q, expected text, %v.", output, outputFormats)
+	}
+	return nil
+}
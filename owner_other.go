@@ -0,0 +1,14 @@
+//go:build !unix
+
+/*
+File Name       : owner_other.go
+
+Purpose         : Fallback for platforms without Unix owners. The rewritten file gets the default
+                  owner of a new file.
+*/
+
+package main
+
+import "os"
+
+func copyOwner(path string, name string, info os.FileInfo) error { return nil }
@@ -0,0 +1,35 @@
+//go:build unix
+
+/*
+File Name       : owner_unix.go
+
+Purpose         : Copies the owner and group of a file to its rewritten copy, so an in-place rewrite
+                  does not hand the file over to the user running sncalc. A user who may write the
+                  file but not give it away gets a warning and the rewrite goes ahead.
+*/
+
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"syscall"
+)
+
+// copyOwner gives the file at path the owner and group of info when they differ from those of the
+// process. Lacking the permission only warns on stderr; name is the file reported.
+func copyOwner(path string, name string, info os.FileInfo) error {
+	st, ok := info.Sys().(*syscall.Stat_t)
+	if !ok || (int(st.Uid) == os.Getuid() && int(st.Gid) == os.Getgid()) {
+		return nil
+	}
+	if err := os.Chown(path, int(st.Uid), int(st.Gid)); err != nil {
+		if errors.Is(err, os.ErrPermission) {
+			fmt.Fprintf(os.Stderr, "%v: cannot keep the owner %v:%v, the rewritten file belongs to you\n", name, st.Uid, st.Gid)
+			return nil
+		}
+		return fmt.Errorf("ERROR: cannot keep the owner %v:%v of %v: %v", st.Uid, st.Gid, name, err)
+	}
+	return nil
+}
@@ -0,0 +1,235 @@
+/*
+File Name       : prefixlist.go
+
+Purpose         : BGP prefix-list generation for peering filters. Each prefix is matched together with
+                  its more specifics between the ge and le lengths. The bounds are validated against
+                  every prefix length, entries already covered by another entry are dropped, and the
+                  result is written as Cisco/FRR "ip prefix-list", Juniper "route-filter" or BIRD
+                  prefix set syntax. IPv4 and IPv6 prefixes may be mixed; -ge/-le apply to both
+                  families, -ge4/-le4 and -ge6/-le6 override them for one family, e.g.
+                    sncalc prefixlist -le4 24 -le6 48 192.0.2.0/23 2001:db8::/32
+
+Usage           : sncalc prefixlist [-syntax cisco|frr|juniper|bird] [-name NAME] [-ge N] [-le N]
+                                    [-ge4 N] [-le4 N] [-ge6 N] [-le6 N] [-f FILE] PREFIX ...
+*/
+
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"net/netip"
+	"sort"
+	"strings"
+)
+
+// prefixListEntry matches prefix and every more specific route whose length is between minLen and
+// maxLen.
+type prefixListEntry struct {
+	prefix netip.Prefix
+	minLen int
+	maxLen int
+}
+
+// covers reports whether every route matched by other is matched by e as well.
+func (e prefixListEntry) covers(other prefixListEntry) bool {
+	return e.prefix.Addr().Is4() == other.prefix.Addr().Is4() &&
+		e.prefix.Bits() <= other.prefix.Bits() &&
+		e.prefix.Contains(other.prefix.Addr()) &&
+		e.minLen <= other.minLen && other.maxLen <= e.maxLen
+}
+
+// newPrefixListEntry validates ge/le (0 = not given) against the prefix length the same way
+// routers do: length < ge <= le <= max bits. With only ge the range extends to the maximum length.
+func newPrefixListEntry(arg string, ge int, le int) (prefixListEntry, error) {
+	prefix, err := netip.ParsePrefix(arg)
+	if err != nil {
+		return prefixListEntry{}, fmt.Errorf("ERROR: %q is not a valid prefix.", arg)
+	}
+	if prefix != prefix.Masked() {
+		return prefixListEntry{}, fmt.Errorf("ERROR: %v has host bits set, the network is %v.", prefix, prefix.Masked())
+	}
+	length := prefix.Bits()
+	maxBits := prefix.Addr().BitLen()
+
+	e := prefixListEntry{prefix: prefix, minLen: length, maxLen: length}
+	if ge != 0 {
+		if ge <= length || ge > maxBits {
+			return prefixListEntry{}, fmt.Errorf("ERROR: ge %v must be greater than %v and at most %v for %v.", ge, length, maxBits, prefix)
+		}
+		e.minLen = ge
+		e.maxLen = maxBits
+	}
+	if le != 0 {
+		if le < e.minLen || le > maxBits {
+			return prefixListEntry{}, fmt.Errorf("ERROR: le %v must be between %v and %v for %v.", le, e.minLen, maxBits, prefix)
+		}
+		e.maxLen = le
+	}
+	return e, nil
+}
+
+// collapsePrefixListEntries removes duplicates and entries covered by another entry.
+func collapsePrefixListEntries(entries []prefixListEntry) []prefixListEntry {
+	sort.SliceStable(entries, func(i, j int) bool {
+		a, b := entries[i], entries[j]
+		if a.prefix.Addr().Is4() != b.prefix.Addr().Is4() {
+			return a.prefix.Addr().Is4()
+		}
+		if c := a.prefix.Addr().Compare(b.prefix.Addr()); c != 0 {
+			return c < 0
+		}
+		if a.prefix.Bits() != b.prefix.Bits() {
+			return a.prefix.Bits() < b.prefix.Bits()
+		}
+		return a.maxLen-a.minLen > b.maxLen-b.minLen
+	})
+	kept := make([]prefixListEntry, 0, len(entries))
+	for _, e := range entries {
+		redundant := false
+		for _, k := range kept {
+			if k.covers(e) {
+				redundant = true
+				break
+			}
+		}
+		if !redundant {
+			kept = append(kept, e)
+		}
+	}
+	return kept
+}
+
+// ciscoPrefixListLine renders one entry in Cisco IOS / FRR syntax.
+func ciscoPrefixListLine(name string, seq int, e prefixListEntry) string {
+	family := "ip"
+	if e.prefix.Addr().Is6() {
+		family = "ipv6"
+	}
+	line := fmt.Sprintf("%v prefix-list %v seq %v permit %v", family, name, seq, e.prefix)
+	if e.minLen > e.prefix.Bits() {
+		line += fmt.Sprintf(" ge %v", e.minLen)
+	}
+	if e.maxLen > e.minLen || e.minLen > e.prefix.Bits() {
+		line += fmt.Sprintf(" le %v", e.maxLen)
+	}
+	return line
+}
+
+// juniperRouteFilter renders one entry as a Junos route-filter match.
+func juniperRouteFilter(e prefixListEntry) string {
+	switch {
+	case e.minLen == e.prefix.Bits() && e.maxLen == e.prefix.Bits():
+		return fmt.Sprintf("route-filter %v exact;", e.prefix)
+	case e.minLen == e.prefix.Bits():
+		return fmt.Sprintf("route-filter %v upto /%v;", e.prefix, e.maxLen)
+	default:
+		return fmt.Sprintf("route-filter %v prefix-length-range /%v-/%v;", e.prefix, e.minLen, e.maxLen)
+	}
+}
+
+// birdPrefixSetItem renders one entry as a BIRD prefix set item.
+func birdPrefixSetItem(e prefixListEntry) string {
+	if e.minLen == e.prefix.Bits() && e.maxLen == e.prefix.Bits() {
+		return e.prefix.String()
+	}
+	return fmt.Sprintf("%v{%v,%v}", e.prefix, e.minLen, e.maxLen)
+}
+
+// printPrefixList writes the entries in the requested syntax.
+func printPrefixList(syntax string, name string, entries []prefixListEntry) error {
+	switch syntax {
+	case "cisco", "frr":
+		seq := map[bool]int{}
+		for _, e := range entries {
+			seq[e.prefix.Addr().Is4()] += 5
+			fmt.Printf("%v\n", ciscoPrefixListLine(name, seq[e.prefix.Addr().Is4()], e))
+		}
+
+	case "juniper":
+		fmt.Printf("policy-options {\n")
+		fmt.Printf("    policy-statement %v {\n", name)
+		fmt.Printf("        term prefixes {\n")
+		fmt.Printf("            from {\n")
+		for _, e := range entries {
+			fmt.Printf("                %v\n", juniperRouteFilter(e))
+		}
+		fmt.Printf("            }\n")
+		fmt.Printf("            then accept;\n")
+		fmt.Printf("        }\n")
+		fmt.Printf("    }\n")
+		fmt.Printf("}\n")
+
+	case "bird":
+		// BIRD prefix sets cannot mix address families.
+		families := []struct {
+			suffix string
+			is4    bool
+		}{{"_V4", true}, {"_V6", false}}
+		for _, family := range families {
+			var items []string
+			for _, e := range entries {
+				if e.prefix.Addr().Is4() == family.is4 {
+					items = append(items, birdPrefixSetItem(e))
+				}
+			}
+			if len(items) == 0 {
+				continue
+			}
+			fmt.Printf("define %v%v = [\n    %v\n];\n", name, family.suffix, strings.Join(items, ",\n    "))
+		}
+
+	default:
+		return fmt.Errorf("ERROR: Unknown syntax %q, expected cisco, frr, juniper or bird.", syntax)
+	}
+	return nil
+}
+
+// prefixListMode builds, validates and prints the prefix-list.
+func prefixListMode(args []string) error {
+	fs := flag.NewFlagSet("prefixlist", flag.ContinueOnError)
+	syntax := fs.String("syntax", "cisco", "output syntax: cisco, frr, juniper or bird")
+	name := fs.String("name", "SNCALC", "prefix-list, policy or set name")
+	ge := fs.Int("ge", 0, "minimum matched prefix length (0 = the prefix length)")
+	le := fs.Int("le", 0, "maximum matched prefix length (0 = the prefix length, or max bits with -ge)")
+	ge4 := fs.Int("ge4", 0, "-ge for IPv4 prefixes only")
+	le4 := fs.Int("le4", 0, "-le for IPv4 prefixes only")
+	ge6 := fs.Int("ge6", 0, "-ge for IPv6 prefixes only")
+	le6 := fs.Int("le6", 0, "-le for IPv6 prefixes only")
+	listFile := fs.String("f", "", "read prefixes from this file (\"-\" for stdin)")
+	if err := parseModeFlags(fs, args); err != nil {
+		return err
+	}
+	// Per family bounds, falling back to -ge/-le.
+	bounds := map[bool][2]int{true: {*ge, *le}, false: {*ge, *le}}
+	if *ge4 != 0 || *le4 != 0 {
+		bounds[true] = [2]int{*ge4, *le4}
+	}
+	if *ge6 != 0 || *le6 != 0 {
+		bounds[false] = [2]int{*ge6, *le6}
+	}
+
+	items := fs.Args()
+	if *listFile != "" {
+		fileItems, err := readAddressList(*listFile)
+		if err != nil {
+			return err
+		}
+		items = append(items, fileItems...)
+	}
+	if len(items) == 0 {
+		return errors.New("ERROR: prefixlist needs at least one prefix.")
+	}
+
+	entries := make([]prefixListEntry, 0, len(items))
+	for _, item := range items {
+		b := bounds[!strings.Contains(item, ":")]
+		e, err := newPrefixListEntry(item, b[0], b[1])
+		if err != nil {
+			return err
+		}
+		entries = append(entries, e)
+	}
+	return printPrefixList(*syntax, *name, collapsePrefixListEntries(entries))
+}
@@ -0,0 +1,107 @@
+/*
+File Name       : prefixtrie.go
+
+Purpose         : Binary trie of IPv4 and IPv6 prefixes for longest-match lookups. A lookup walks at
+                  most 32 or 128 nodes no matter how many prefixes are loaded, so matching every
+                  address of a large log against a long network list stays fast.
+*/
+
+package main
+
+import (
+	"fmt"
+	"net/netip"
+	"strings"
+)
+
+// prefixTrieNode is one bit position; end is set when a prefix ends here.
+type prefixTrieNode struct {
+	child  [2]*prefixTrieNode
+	end    bool
+	prefix netip.Prefix
+	label  string
+}
+
+// prefixTrie holds separate trees for both address families.
+type prefixTrie struct {
+	v4 prefixTrieNode
+	v6 prefixTrieNode
+}
+
+// addrBytes returns the bytes of addr; an IPv4 address fills the first four.
+func addrBytes(addr netip.Addr) [16]byte {
+	if addr.Is4() {
+		var b [16]byte
+		v4 := addr.As4()
+		copy(b[:], v4[:])
+		return b
+	}
+	return addr.As16()
+}
+
+// addrBit returns bit i of the address bytes b, counted from the most significant bit.
+func addrBit(b *[16]byte, i int) int {
+	return int(b[i/8]>>(7-i%8)) & 1
+}
+
+// root returns the tree for the family of addr.
+func (t *prefixTrie) root(addr netip.Addr) *prefixTrieNode {
+	if addr.Is4() {
+		return &t.v4
+	}
+	return &t.v6
+}
+
+// insert adds prefix with a label; inserting it again replaces the label.
+func (t *prefixTrie) insert(prefix netip.Prefix, label string) {
+	prefix = prefix.Masked()
+	node := t.root(prefix.Addr())
+	b := addrBytes(prefix.Addr())
+	for i := 0; i < prefix.Bits(); i++ {
+		bit := addrBit(&b, i)
+		if node.child[bit] == nil {
+			node.child[bit] = &prefixTrieNode{}
+		}
+		node = node.child[bit]
+	}
+	node.end, node.prefix, node.label = true, prefix, label
+}
+
+// lookup returns the most specific prefix containing addr and its label. IPv4-mapped IPv6
+// addresses are looked up as IPv4.
+func (t *prefixTrie) lookup(addr netip.Addr) (netip.Prefix, string, bool) {
+	addr = addr.Unmap()
+	node := t.root(addr)
+	b := addrBytes(addr)
+	var match *prefixTrieNode
+	for i := 0; node != nil; i++ {
+		if node.end {
+			match = node
+		}
+		if i == addr.BitLen() {
+			break
+		}
+		node = node.child[addrBit(&b, i)]
+	}
+	if match == nil {
+		return netip.Prefix{}, "", false
+	}
+	return match.prefix, match.label, true
+}
+
+// parseNetworkArg reads an IPv4 or IPv6 prefix; a bare address is a host prefix.
+func parseNetworkArg(s string) (netip.Prefix, error) {
+	s = strings.TrimSpace(s)
+	if strings.Contains(s, "/") {
+		prefix, err := netip.ParsePrefix(s)
+		if err != nil {
+			return netip.Prefix{}, fmt.Errorf("ERROR: %q is not a valid network.", s)
+		}
+		return prefix.Masked(), nil
+	}
+	addr, err := netip.ParseAddr(s)
+	if err != nil {
+		return netip.Prefix{}, fmt.Errorf("ERROR: %q is not a valid address or network.", s)
+	}
+	return netip.PrefixFrom(addr, addr.BitLen()), nil
+}
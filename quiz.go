@@ -0,0 +1,182 @@
+/*
+File Name       : quiz.go
+
+Purpose         : Subnetting practice for CCNA preparation. Generates random address/prefix questions
+                  (network, broadcast, usable range, mask, wildcard, number of subnets, usable hosts),
+                  checks every answer against the calculator and explains wrong answers with the
+                  --explain derivation. The same seed always produces the same quiz, so a trainer can
+                  hand it out twice.
+
+Usage           : sncalc quiz [-n N] [-difficulty easy|medium|hard] [-seed N]
+                  easy: /24 to /30 in 192.168.x.x, medium: /16 to /30 in private ranges,
+                  hard: /1 to /30 anywhere in unicast space.
+*/
+
+package main
+
+import (
+	"bufio"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"math/rand"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// quizQuestion is one generated question with its correct answer.
+type quizQuestion struct {
+	ipv4   string
+	cidr   int
+	kind   string
+	prompt string
+	answer string
+}
+
+// quizKinds are the kinds of question asked, in rotation order.
+var quizKinds = []string{"network", "broadcast", "range", "mask", "wildcard", "subnets", "hosts"}
+
+// randomQuizInput picks an address and prefix for the difficulty.
+func randomQuizInput(r *rand.Rand, difficulty string) (string, int) {
+	switch difficulty {
+	case "easy":
+		return fmt.Sprintf("192.168.%v.%v", r.Intn(256), r.Intn(256)), 24 + r.Intn(7)
+	case "medium":
+		var ip string
+		switch r.Intn(3) {
+		case 0:
+			ip = fmt.Sprintf("10.%v.%v.%v", r.Intn(256), r.Intn(256), r.Intn(256))
+		case 1:
+			ip = fmt.Sprintf("172.%v.%v.%v", 16+r.Intn(16), r.Intn(256), r.Intn(256))
+		default:
+			ip = fmt.Sprintf("192.168.%v.%v", r.Intn(256), r.Intn(256))
+		}
+		return ip, 16 + r.Intn(15)
+	default:
+		return fmt.Sprintf("%v.%v.%v.%v", 1+r.Intn(223), r.Intn(256), r.Intn(256), r.Intn(256)), 1 + r.Intn(30)
+	}
+}
+
+// newQuizQuestion builds a question of the given kind for ipv4/cidr.
+func newQuizQuestion(ipv4 string, cidr int, kind string) (quizQuestion, error) {
+	res, err := calculate(ipv4, cidr)
+	if err != nil {
+		return quizQuestion{}, err
+	}
+	q := quizQuestion{ipv4: ipv4, cidr: cidr, kind: kind}
+	host := fmt.Sprintf("%v/%v", ipv4, cidr)
+	switch kind {
+	case "network":
+		q.prompt, q.answer = fmt.Sprintf("What is the network address of %v?", host), res.NetworkAddress
+	case "broadcast":
+		q.prompt, q.answer = fmt.Sprintf("What is the broadcast address of %v?", host), res.BroadcastAddress
+	case "range":
+		q.prompt = fmt.Sprintf("What is the usable host range of %v? (first - last)", host)
+		q.answer = fmt.Sprintf("%v - %v", res.FirstUsable, res.LastUsable)
+	case "mask":
+		q.prompt, q.answer = fmt.Sprintf("What is the subnet mask of a /%v?", cidr), res.SubnetMask
+	case "wildcard":
+		q.prompt, q.answer = fmt.Sprintf("What is the wildcard mask of a /%v?", cidr), res.WildcardMask
+	case "subnets":
+		q.prompt = fmt.Sprintf("How many /%v subnets are there in the %v octet of %v?", cidr, []string{"", "1st", "2nd", "3rd", "4th"}[res.SubnetOctet], ipv4)
+		q.answer = strconv.FormatInt(res.NumberOfSubnets, 10)
+	case "hosts":
+		q.prompt, q.answer = fmt.Sprintf("How many valid (usable) hosts does a /%v have?", cidr), strconv.FormatInt(res.UsableHosts, 10)
+	}
+	return q, nil
+}
+
+// quizAnswerCorrect compares an answer leniently: whitespace is ignored and addresses may be
+// written in any form ipToInt accepts.
+func quizAnswerCorrect(q quizQuestion, given string) bool {
+	normalize := func(s string) string { return strings.Join(strings.Fields(s), "") }
+	given = normalize(given)
+	if given == normalize(q.answer) {
+		return true
+	}
+	switch q.kind {
+	case "network", "broadcast", "mask", "wildcard":
+		givenIP, err := ipToInt(given)
+		wantIP, _ := ipToInt(q.answer)
+		return err == nil && givenIP == wantIP
+	case "range":
+		first, last, found := strings.Cut(given, "-")
+		if !found {
+			return false
+		}
+		wantFirst, wantLast, _ := strings.Cut(normalize(q.answer), "-")
+		firstIP, err1 := ipToInt(first)
+		lastIP, err2 := ipToInt(last)
+		wantFirstIP, _ := ipToInt(wantFirst)
+		wantLastIP, _ := ipToInt(wantLast)
+		return err1 == nil && err2 == nil && firstIP == wantFirstIP && lastIP == wantLastIP
+	}
+	return false
+}
+
+// runQuiz asks count questions on w, reads the answers from in and prints the score. End of input
+// stops the quiz early and scores the questions answered so far.
+func runQuiz(in io.Reader, w io.Writer, count int, difficulty string, seed int64) error {
+	r := rand.New(rand.NewSource(seed))
+	scanner := bufio.NewScanner(in)
+
+	fmt.Fprintf(w, "Subnetting quiz: %v questions, difficulty %v, seed %v\n", count, difficulty, seed)
+	asked, correct := 0, 0
+	for i := 0; i < count; i++ {
+		ipv4, cidr := randomQuizInput(r, difficulty)
+		q, err := newQuizQuestion(ipv4, cidr, quizKinds[r.Intn(len(quizKinds))])
+		if err != nil {
+			return err
+		}
+
+		fmt.Fprintf(w, "\nQuestion %v: %v\n> ", i+1, q.prompt)
+		if !scanner.Scan() {
+			fmt.Fprintf(w, "\n")
+			break
+		}
+		asked++
+		if quizAnswerCorrect(q, scanner.Text()) {
+			correct++
+			fmt.Fprintf(w, "Correct.\n")
+			continue
+		}
+		fmt.Fprintf(w, "Wrong, the answer is %v.\n\n", q.answer)
+		if err := writeExplanation(w, q.ipv4, q.cidr); err != nil {
+			return err
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("ERROR: %v", err)
+	}
+
+	percent := 0
+	if asked > 0 {
+		percent = correct * 100 / asked
+	}
+	fmt.Fprintf(w, "\nScore: %v/%v (%v%%)\n", correct, asked, percent)
+	return nil
+}
+
+// quizMode parses the quiz options and runs it on stdin/stdout.
+func quizMode(args []string) error {
+	fs := flag.NewFlagSet("quiz", flag.ContinueOnError)
+	count := fs.Int("n", 10, "number of questions")
+	difficulty := fs.String("difficulty", "easy", "easy, medium or hard")
+	seed := fs.Int64("seed", 0, "random seed; the same seed gives the same quiz (0 = pick one)")
+	if err := parseModeFlags(fs, args); err != nil {
+		return err
+	}
+	if *count < 1 {
+		return errors.New("ERROR: -n must be at least 1.")
+	}
+	if *difficulty != "easy" && *difficulty != "medium" && *difficulty != "hard" {
+		return fmt.Errorf("ERROR: Unknown difficulty %q, expected easy, medium or hard.", *difficulty)
+	}
+	if *seed == 0 {
+		*seed = time.Now().UnixNano()
+	}
+	return runQuiz(os.Stdin, os.Stdout, *count, *difficulty, *seed)
+}
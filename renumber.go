@@ -0,0 +1,312 @@
+/*
+File Name       : renumber.go
+
+Purpose         : Renumbering helper. Maps every address of the old network to the address with the same
+                  host offset in the new network, e.g. 172.16.8.0/21 to 10.60.8.0/21:
+                    172.16.8.1    => 10.60.8.1
+                    172.16.13.77  => 10.60.13.77
+                  Without files the mapping table is printed. With files every old address in them is
+                  rewritten, as are prefixes (the old network itself gets the new prefix length) and
+                  the subnet or wildcard mask following the old network address. -dry-run prints the
+                  changes as a unified diff instead of writing the files; "-" filters stdin to stdout.
+
+                  When the networks differ in size, -truncate has to say what happens. For a smaller
+                  new network it decides the offsets the new network does not have:
+                    keep  leave those addresses unchanged and report them
+                    wrap  use the offset modulo the size of the new network
+                  For a larger new network every offset exists; it decides the prefixes and masks of
+                  the old network:
+                    keep  keep the old prefix length, so an ACL still matches as many addresses
+                          (wrap does the same)
+                    grow  use the prefix length of the new network
+
+Usage           : sncalc renumber -from 172.16.8.0/21 -to 10.60.8.0/21 [-truncate keep|wrap|grow]
+                                  [-dry-run] [FILE...]
+*/
+
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/netip"
+	"os"
+	"path/filepath"
+	"strconv"
+)
+
+// addressMapping maps the addresses of one network to another by host offset.
+type addressMapping struct {
+	from     uint32
+	fromCidr int
+	to       uint32
+	toCidr   int
+	truncate string
+	// rewriteCidr is the prefix length the old network and its masks are rewritten to.
+	rewriteCidr int
+}
+
+// newAddressMapping parses both networks and checks the truncation policy, which is needed when
+// the networks differ in size.
+func newAddressMapping(from string, to string, truncate string) (*addressMapping, error) {
+	fromIP, fromCidr, err := parseCidr(from)
+	if err != nil {
+		return nil, err
+	}
+	toIP, toCidr, err := parseCidr(to)
+	if err != nil {
+		return nil, err
+	}
+	switch truncate {
+	case "", "keep", "wrap", "grow":
+	default:
+		return nil, fmt.Errorf("ERROR: Unknown truncation policy %q, expected keep, wrap or grow.", truncate)
+	}
+	if fromCidr != toCidr && truncate == "" {
+		return nil, fmt.Errorf("ERROR: /%v and /%v differ in size, choose a policy with -truncate keep|wrap|grow.", fromCidr, toCidr)
+	}
+	if toCidr > fromCidr && truncate == "grow" {
+		return nil, fmt.Errorf("ERROR: -truncate grow needs a larger new network, /%v is smaller than /%v.", toCidr, fromCidr)
+	}
+	rewriteCidr := toCidr
+	if toCidr < fromCidr && truncate != "grow" {
+		rewriteCidr = fromCidr
+	}
+	return &addressMapping{
+		from:        fromIP & cidrToMaskInt(fromCidr),
+		fromCidr:    fromCidr,
+		to:          toIP & cidrToMaskInt(toCidr),
+		toCidr:      toCidr,
+		truncate:    truncate,
+		rewriteCidr: rewriteCidr,
+	}, nil
+}
+
+// contains reports whether ip is in the old network.
+func (m *addressMapping) contains(ip uint32) bool {
+	return ip&cidrToMaskInt(m.fromCidr) == m.from
+}
+
+// mapAddress returns the address with the same host offset in the new network. ok is false for
+// addresses outside the old network and for offsets the new network does not have under "keep".
+func (m *addressMapping) mapAddress(ip uint32) (uint32, bool) {
+	if !m.contains(ip) {
+		return 0, false
+	}
+	offset := uint64(ip - m.from)
+	size := uint64(1) << (ipTotalBitCount - m.toCidr)
+	if offset >= size {
+		if m.truncate != "wrap" {
+			return 0, false
+		}
+		offset %= size
+	}
+	return m.to + uint32(offset), true
+}
+
+// renumberLine rewrites the old addresses of line. Addresses without a counterpart are passed to
+// report. It returns the new line and the number of replaced addresses.
+func renumberLine(line []byte, m *addressMapping, report func(ip uint32)) ([]byte, int) {
+	var out bytes.Buffer
+	written, replaced := 0, 0
+	maskFollows := false
+	scanAddrs(line, func(start int, end int, addr netip.Addr) bool {
+		if !addr.Is4() {
+			return true
+		}
+		b := addr.As4()
+		ip := uint32(b[0])<<24 | uint32(b[1])<<16 | uint32(b[2])<<8 | uint32(b[3])
+
+		// The mask following the old network address, as in "172.16.8.0 255.255.248.0".
+		if maskFollows && len(bytes.TrimSpace(line[written:start])) == 0 {
+			maskFollows = false
+			replacement := ""
+			if ip == cidrToMaskInt(m.fromCidr) {
+				replacement = intToIP(cidrToMaskInt(m.rewriteCidr))
+			} else if ip == ^cidrToMaskInt(m.fromCidr) {
+				replacement = intToIP(^cidrToMaskInt(m.rewriteCidr))
+			}
+			if replacement != "" {
+				out.Write(line[written:start])
+				out.WriteString(replacement)
+				written = end
+				return true
+			}
+		}
+		maskFollows = false
+
+		mapped, ok := m.mapAddress(ip)
+		if !ok {
+			if m.contains(ip) {
+				report(ip)
+			}
+			return true
+		}
+		out.Write(line[written:start])
+		out.WriteString(intToIP(mapped))
+		written = end
+		replaced++
+
+		if ip == m.from {
+			// The old network as a prefix gets the new length; as an address it may be
+			// followed by its mask.
+			if prefixLength, n := readPrefixLength(line[end:]); n > 0 && prefixLength == m.fromCidr {
+				out.WriteString("/" + strconv.Itoa(m.rewriteCidr))
+				written = end + n
+			} else {
+				maskFollows = true
+			}
+		}
+		return true
+	})
+	if replaced == 0 && written == 0 {
+		return line, 0
+	}
+	out.Write(line[written:])
+	return out.Bytes(), replaced
+}
+
+// readPrefixLength reads a "/N" at the start of b and returns N and the number of bytes used.
+func readPrefixLength(b []byte) (int, int) {
+	if len(b) < 2 || b[0] != '/' {
+		return 0, 0
+	}
+	n := 1
+	for n < len(b) && n < 4 && b[n] >= '0' && b[n] <= '9' {
+		n++
+	}
+	if n == 1 || (n < len(b) && isWordByte(b[n])) {
+		return 0, 0
+	}
+	prefixLength, _ := strconv.Atoi(string(b[1:n]))
+	return prefixLength, n
+}
+
+// renumberFile rewrites one file, or prints its changes as a diff with dryRun. "-" filters stdin to
+// stdout.
+func renumberFile(name string, m *addressMapping, dryRun bool, w *bufio.Writer) error {
+	in := io.Reader(os.Stdin)
+	if name != "-" {
+		f, err := os.Open(name)
+		if err != nil {
+			return fmt.Errorf("ERROR: %v", err)
+		}
+		defer f.Close()
+		in = f
+	}
+
+	var rewritten bytes.Buffer
+	lineNumber, replaced, headerDone := 0, 0, false
+	err := forEachLine(in, func(line []byte) {
+		lineNumber++
+		newLine, n := renumberLine(line, m, func(ip uint32) {
+			fmt.Fprintf(os.Stderr, "%v:%v: %v has no address in %v/%v, left unchanged\n", name, lineNumber, intToIP(ip), intToIP(m.to), m.toCidr)
+		})
+		replaced += n
+		if name == "-" && !dryRun {
+			w.Write(newLine)
+			return
+		}
+		rewritten.Write(newLine)
+		if dryRun && n > 0 {
+			if !headerDone {
+				fmt.Fprintf(w, "--- %v\n+++ %v\n", name, name)
+				headerDone = true
+			}
+			fmt.Fprintf(w, "@@ -%v +%v @@\n-%s", lineNumber, lineNumber, line)
+			if line[len(line)-1] != '\n' {
+				fmt.Fprintf(w, "\n\\ No newline at end of file\n")
+			}
+			fmt.Fprintf(w, "+%s", newLine)
+			if newLine[len(newLine)-1] != '\n' {
+				fmt.Fprintf(w, "\n\\ No newline at end of file\n")
+			}
+		}
+	})
+	if err != nil || name == "-" || dryRun || replaced == 0 {
+		return err
+	}
+
+	// Write next to the file and rename, so an interrupted run leaves the original intact. A symlink
+	// is followed so the link stays and its target is rewritten; mode, owner and group are kept.
+	path, err := filepath.EvalSymlinks(name)
+	if err != nil {
+		return fmt.Errorf("ERROR: %v", err)
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return fmt.Errorf("ERROR: %v", err)
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".renumber")
+	if err != nil {
+		return fmt.Errorf("ERROR: %v", err)
+	}
+	_, err = tmp.Write(rewritten.Bytes())
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Chmod(tmp.Name(), info.Mode().Perm())
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+		return fmt.Errorf("ERROR: %v", err)
+	}
+	if err := copyOwner(tmp.Name(), name, info); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		os.Remove(tmp.Name())
+		return fmt.Errorf("ERROR: %v", err)
+	}
+	fmt.Fprintf(w, "%v: %v addresses renumbered\n", name, replaced)
+	return nil
+}
+
+// renumberMode prints the mapping table or rewrites the named files.
+func renumberMode(args []string) error {
+	fs := flag.NewFlagSet("renumber", flag.ContinueOnError)
+	from := fs.String("from", "", "old network, IP/CIDR")
+	to := fs.String("to", "", "new network, IP/CIDR")
+	truncate := fs.String("truncate", "", "policy when the networks differ in size: keep or wrap (smaller), keep or grow (larger)")
+	dryRun := fs.Bool("dry-run", false, "print the changes as a diff instead of rewriting the files")
+	if err := parseModeFlags(fs, args); err != nil {
+		return err
+	}
+	if *from == "" || *to == "" {
+		return errors.New("ERROR: renumber needs -from and -to.")
+	}
+	m, err := newAddressMapping(*from, *to, *truncate)
+	if err != nil {
+		return err
+	}
+
+	w := bufio.NewWriter(os.Stdout)
+	defer w.Flush()
+	if fs.NArg() == 0 {
+		fmt.Fprintf(w, "  %-20v %v\n", fmt.Sprintf("%v/%v", intToIP(m.from), m.fromCidr), fmt.Sprintf("%v/%v", intToIP(m.to), m.toCidr))
+		fmt.Fprintf(w, "  %-20v %v\n", "---------------", "---------------")
+		size := uint64(1) << (ipTotalBitCount - m.fromCidr)
+		for offset := uint64(0); offset < size; offset++ {
+			ip := m.from + uint32(offset)
+			mapped, ok := m.mapAddress(ip)
+			target := "(unchanged, outside the new network)"
+			if ok {
+				target = intToIP(mapped)
+			}
+			fmt.Fprintf(w, "  %-20v %v\n", intToIP(ip), target)
+		}
+		return nil
+	}
+	for _, name := range fs.Args() {
+		if err := renumberFile(name, m, *dryRun, w); err != nil {
+			return err
+		}
+	}
+	return nil
+}
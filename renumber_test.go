@@ -0,0 +1,69 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestRenumberLine(t *testing.T) {
+	for _, tc := range []struct {
+		from, to, truncate string
+		line, want         string
+		replaced           int
+		reported           []string
+	}{
+		// Same size: addresses, prefixes and masks of the old network.
+		{"172.16.8.0/21", "10.60.8.0/21", "", "host 172.16.13.77:22", "host 10.60.13.77:22", 1, nil},
+		{"172.16.8.0/21", "10.60.8.0/21", "", "ip route 172.16.8.0/21 eth0", "ip route 10.60.8.0/21 eth0", 1, nil},
+		{"172.16.8.0/21", "10.60.8.0/21", "", "network 172.16.8.0 255.255.248.0", "network 10.60.8.0 255.255.248.0", 1, nil},
+		{"172.16.8.0/21", "10.60.8.0/21", "", "ends in 172.16.15.255.", "ends in 10.60.15.255.", 1, nil},
+		{"172.16.8.0/21", "10.60.8.0/21", "", "1.2.3.4,172.16.8.1", "1.2.3.4,10.60.8.1", 1, nil},
+		// Outside the old network, glued to words, or a /N that is not the old prefix length.
+		{"172.16.8.0/21", "10.60.8.0/21", "", "via 172.16.16.1 and 192.168.1.1", "via 172.16.16.1 and 192.168.1.1", 0, nil},
+		{"172.16.8.0/21", "10.60.8.0/21", "", "x172.16.8.1 172.16.8.1.example", "x172.16.8.1 172.16.8.1.example", 0, nil},
+		{"172.16.8.0/21", "10.60.8.0/21", "", "172.16.8.0/24", "10.60.8.0/24", 1, nil},
+		{"172.16.8.0/21", "10.60.8.0/21", "", "172.16.8.0/210", "10.60.8.0/210", 1, nil},
+		// A mask only counts right after the old network address.
+		{"172.16.8.0/21", "10.60.8.0/22", "keep", "mask 255.255.248.0", "mask 255.255.248.0", 0, nil},
+		// Smaller new network: prefixes and masks shrink, missing offsets are kept and reported.
+		{"172.16.8.0/21", "10.60.8.0/22", "keep", "network 172.16.8.0 255.255.248.0", "network 10.60.8.0 255.255.252.0", 1, nil},
+		{"172.16.8.0/21", "10.60.8.0/22", "keep", "permit 172.16.8.0 0.0.7.255", "permit 10.60.8.0 0.0.3.255", 1, nil},
+		{"172.16.8.0/21", "10.60.8.0/22", "keep", "a 172.16.9.1 b 172.16.13.1 c 172.16.8.0/21", "a 10.60.9.1 b 172.16.13.1 c 10.60.8.0/22", 2, []string{"172.16.13.1"}},
+		{"172.16.8.0/21", "10.60.8.0/22", "wrap", "b 172.16.13.1", "b 10.60.9.1", 1, nil},
+		// Larger new network: keep leaves the prefix length and masks, grow widens them.
+		{"172.16.8.0/21", "10.60.0.0/20", "keep", "permit 172.16.8.0 0.0.7.255 172.16.8.0/21", "permit 10.60.0.0 0.0.7.255 10.60.0.0/21", 2, nil},
+		{"172.16.8.0/21", "10.60.0.0/20", "grow", "permit 172.16.8.0 0.0.7.255 172.16.8.0/21", "permit 10.60.0.0 0.0.15.255 10.60.0.0/20", 2, nil},
+	} {
+		m, err := newAddressMapping(tc.from, tc.to, tc.truncate)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var reported []string
+		got, replaced := renumberLine([]byte(tc.line), m, func(ip uint32) {
+			reported = append(reported, intToIP(ip))
+		})
+		if string(got) != tc.want || replaced != tc.replaced || !reflect.DeepEqual(reported, tc.reported) {
+			t.Errorf("%v => %v %v: renumberLine(%q) = %q, %v, reported %v; want %q, %v, reported %v",
+				tc.from, tc.to, tc.truncate, tc.line, got, replaced, reported, tc.want, tc.replaced, tc.reported)
+		}
+	}
+}
+
+func TestNewAddressMappingPolicy(t *testing.T) {
+	for _, tc := range []struct {
+		from, to, truncate string
+		ok                 bool
+	}{
+		{"172.16.8.0/21", "10.60.8.0/21", "", true},
+		{"172.16.8.0/21", "10.60.8.0/22", "", false},
+		{"172.16.8.0/21", "10.60.0.0/20", "", false},
+		{"172.16.8.0/21", "10.60.8.0/22", "grow", false},
+		{"172.16.8.0/21", "10.60.0.0/20", "grow", true},
+		{"172.16.8.0/21", "10.60.8.0/21", "shrink", false},
+	} {
+		_, err := newAddressMapping(tc.from, tc.to, tc.truncate)
+		if (err == nil) != tc.ok {
+			t.Errorf("newAddressMapping(%v, %v, %q) error = %v, want ok %v", tc.from, tc.to, tc.truncate, err, tc.ok)
+		}
+	}
+}
@@ -0,0 +1,292 @@
+/*
+File Name       : repl.go
+
+Purpose         : Interactive shell for planning sessions. Every line is a calculation or a command
+                  working on the last result:
+                    10.0.0.0/16           calculate (a bare address keeps the current prefix length)
+                    corp = 10.0.0.0/8     store a network in a variable; "corp" then calculates it
+                    split /24             list the subnets of the current network
+                    next [N], prev [N]    move to the adjacent network of the same size
+                    contains 10.0.3.7     check an address against the current network
+                    explain, bits         --explain and --bits for the current network
+                    vars, help, quit
+                  History is kept in ~/.sncalc_history, Tab completes commands and variable names and
+                  Ctrl-D exits.
+
+Usage           : sncalc repl
+*/
+
+package main
+
+import (
+	"bufio"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// replCommands are the command words offered by tab completion.
+var replCommands = []string{"split", "next", "prev", "contains", "explain", "bits", "vars", "help", "quit", "exit"}
+
+// replVariableName matches names accepted on the left side of "=".
+var replVariableName = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_-]*$`)
+
+// replSession holds the state of one interactive session.
+type replSession struct {
+	out       io.Writer
+	current   *calcResult
+	variables map[string]string
+}
+
+// resolve turns a network expression (IP/CIDR, IP or variable name) into address and prefix.
+func (s *replSession) resolve(expr string) (string, int, error) {
+	if value, ok := s.variables[expr]; ok {
+		expr = value
+	}
+	if strings.Contains(expr, "/") {
+		ip, cidr, err := parseCidr(expr)
+		if err != nil {
+			return "", 0, err
+		}
+		return intToIP(ip), cidr, nil
+	}
+	ip, err := ipToInt(expr)
+	if err != nil {
+		return "", 0, fmt.Errorf("ERROR: %q is not an address, network or variable.", expr)
+	}
+	cidr := ipTotalBitCount
+	if s.current != nil {
+		cidr = s.current.PrefixLength
+	}
+	return intToIP(ip), cidr, nil
+}
+
+// setCurrent calculates ipv4/cidr, prints it and makes it the context of following commands.
+func (s *replSession) setCurrent(ipv4 string, cidr int) error {
+	r, err := calculate(ipv4, cidr)
+	if err != nil {
+		return err
+	}
+	s.current = r
+	writeTextResult(s.out, r)
+	return nil
+}
+
+// requireCurrent returns an error for commands that need a previous result.
+func (s *replSession) requireCurrent() error {
+	if s.current == nil {
+		return errors.New("ERROR: No current network, calculate one first, e.g. 10.0.0.0/16.")
+	}
+	return nil
+}
+
+// execute runs one input line. It returns io.EOF for quit/exit.
+func (s *replSession) execute(line string) error {
+	fields := strings.Fields(line)
+	if len(fields) == 0 {
+		return nil
+	}
+
+	if name, value, found := strings.Cut(line, "="); found {
+		name, value = strings.TrimSpace(name), strings.TrimSpace(value)
+		if !replVariableName.MatchString(name) {
+			return fmt.Errorf("ERROR: %q is not a valid variable name.", name)
+		}
+		ipv4, cidr, err := s.resolve(value)
+		if err != nil {
+			return err
+		}
+		s.variables[name] = fmt.Sprintf("%v/%v", ipv4, cidr)
+		fmt.Fprintf(s.out, "%v = %v\n", name, s.variables[name])
+		return nil
+	}
+
+	switch fields[0] {
+	case "quit", "exit":
+		return io.EOF
+
+	case "help":
+		fmt.Fprintf(s.out, "IP/CIDR | IP | NAME          calculate\n")
+		fmt.Fprintf(s.out, "NAME = IP/CIDR                store a network\n")
+		fmt.Fprintf(s.out, "split /N                      subnets of the current network\n")
+		fmt.Fprintf(s.out, "next [N] | prev [N]           adjacent network of the same size\n")
+		fmt.Fprintf(s.out, "contains IP                   is IP inside the current network\n")
+		fmt.Fprintf(s.out, "explain | bits                derivation or binary view\n")
+		fmt.Fprintf(s.out, "vars | help | quit            (Ctrl-D exits as well)\n")
+
+	case "vars":
+		names := make([]string, 0, len(s.variables))
+		for name := range s.variables {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			fmt.Fprintf(s.out, "%v = %v\n", name, s.variables[name])
+		}
+
+	case "split":
+		if err := s.requireCurrent(); err != nil {
+			return err
+		}
+		if len(fields) != 2 {
+			return errors.New("ERROR: split needs a prefix length, e.g. split /24.")
+		}
+		newCidr, err := strconv.Atoi(strings.TrimPrefix(fields[1], "/"))
+		if err != nil {
+			return fmt.Errorf("ERROR: %q is not a valid CIDR value.", fields[1])
+		}
+		rows, err := splitNetwork(s.current.Integer, s.current.PrefixLength, newCidr)
+		if err != nil {
+			return err
+		}
+		for _, row := range rows {
+			fmt.Fprintf(s.out, "  %-20v %-40v %v\n", fmt.Sprintf("%v/%v", row.NetworkAddress, row.PrefixLength), row.FirstUsable+" - "+row.LastUsable, row.BroadcastAddress)
+		}
+
+	case "next", "prev":
+		if err := s.requireCurrent(); err != nil {
+			return err
+		}
+		count := int64(1)
+		if len(fields) == 2 {
+			var err error
+			count, err = strconv.ParseInt(fields[1], 10, 64)
+			if err != nil || count < 0 {
+				return fmt.Errorf("ERROR: %q is not a valid subnet count.", fields[1])
+			}
+		}
+		if fields[0] == "prev" {
+			count = -count
+		}
+		networkAddress, _ := ipToInt(s.current.NetworkAddress)
+		subnet, err := adjacentSubnet(networkAddress, s.current.PrefixLength, count)
+		if err != nil {
+			return err
+		}
+		return s.setCurrent(intToIP(subnet), s.current.PrefixLength)
+
+	case "contains":
+		if err := s.requireCurrent(); err != nil {
+			return err
+		}
+		if len(fields) != 2 {
+			return errors.New("ERROR: contains needs an IP address.")
+		}
+		ip, err := ipToInt(fields[1])
+		if err != nil {
+			return err
+		}
+		mask := cidrToMaskInt(s.current.PrefixLength)
+		answer := "no"
+		if ip&mask == s.current.Integer&mask {
+			answer = "yes"
+		}
+		fmt.Fprintf(s.out, "%v in %v/%v: %v\n", intToIP(ip), s.current.NetworkAddress, s.current.PrefixLength, answer)
+
+	case "explain":
+		if err := s.requireCurrent(); err != nil {
+			return err
+		}
+		return writeExplanation(s.out, s.current.IPAddress, s.current.PrefixLength)
+
+	case "bits":
+		if err := s.requireCurrent(); err != nil {
+			return err
+		}
+		return writeBitMap(s.out, s.current.IPAddress, s.current.PrefixLength, stdoutIsTerminal())
+
+	default:
+		if len(fields) != 1 {
+			return fmt.Errorf("ERROR: Unknown command %q, type help for a list.", fields[0])
+		}
+		ipv4, cidr, err := s.resolve(fields[0])
+		if err != nil {
+			return err
+		}
+		return s.setCurrent(ipv4, cidr)
+	}
+	return nil
+}
+
+// completions returns the commands and variable names starting with word.
+func (s *replSession) completions(word string) []string {
+	var candidates []string
+	for _, c := range replCommands {
+		if strings.HasPrefix(c, word) {
+			candidates = append(candidates, c)
+		}
+	}
+	for name := range s.variables {
+		if strings.HasPrefix(name, word) {
+			candidates = append(candidates, name)
+		}
+	}
+	return candidates
+}
+
+// replHistoryFile returns the path of the history file, or "" without a home directory.
+func replHistoryFile() string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(home, ".sncalc_history")
+}
+
+// replMode runs the interactive shell until quit or end of input.
+func replMode(args []string) error {
+	fs := flag.NewFlagSet("repl", flag.ContinueOnError)
+	if err := parseModeFlags(fs, args); err != nil {
+		return err
+	}
+	if fs.NArg() != 0 {
+		return errors.New("ERROR: repl takes no arguments.")
+	}
+	s := &replSession{out: os.Stdout, variables: make(map[string]string)}
+	editor := newLineEditor(s.completions)
+
+	historyPath := replHistoryFile()
+	var history *os.File
+	if historyPath != "" && editor.terminal {
+		if f, err := os.Open(historyPath); err == nil {
+			scanner := bufio.NewScanner(f)
+			for scanner.Scan() {
+				editor.addHistory(scanner.Text())
+			}
+			f.Close()
+		}
+		history, _ = os.OpenFile(historyPath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+		if history != nil {
+			defer history.Close()
+		}
+	}
+
+	for {
+		line, err := editor.readLine("sncalc> ")
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("ERROR: %v", err)
+		}
+		line = strings.TrimSpace(line)
+		if line != "" && editor.terminal {
+			editor.addHistory(line)
+			if history != nil {
+				fmt.Fprintln(history, line)
+			}
+		}
+		if err := s.execute(line); err == io.EOF {
+			return nil
+		} else if err != nil {
+			fmt.Fprintln(s.out, err)
+		}
+	}
+}
@@ -0,0 +1,147 @@
+/*
+File Name       : result.go
+
+Purpose         : Typed calculation result behind every output. calcResult holds the figures as
+                  plain numbers and strings, and the subnet list as rows with a current flag; the text
+                  summary (writeTextResult) adds explanations like "64   (2^unmasked bits) => (2^6)"
+                  and the "[current]" suffix when printing it.
+
+                  resultSchemaVersion has to be increased whenever a field is renamed, removed or
+                  changes type, so consumers can detect incompatible output.
+*/
+
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+const resultSchemaVersion int = 1
+
+// subnetRow is one line of the subnet list.
+type subnetRow struct {
+	NetworkAddress   string `json:"network_address"`
+	FirstUsable      string `json:"first_usable"`
+	LastUsable       string `json:"last_usable"`
+	BroadcastAddress string `json:"broadcast_address"`
+	PrefixLength     int    `json:"prefix_length"`
+	TotalHosts       int64  `json:"total_hosts"`
+	UsableHosts      int64  `json:"usable_hosts"`
+	Current          bool   `json:"current"`
+}
+
+// calcResult holds every field of the summary. Addresses without a meaningful value (no usable
+// hosts in a /31 or /32) are empty strings.
+type calcResult struct {
+	SchemaVersion    int         `json:"schema_version"`
+	IPAddress        string      `json:"ip_address"`
+	NetworkAddress   string      `json:"network_address"`
+	FirstUsable      string      `json:"first_usable"`
+	LastUsable       string      `json:"last_usable"`
+	BroadcastAddress string      `json:"broadcast_address"`
+	TotalHosts       int64       `json:"total_hosts"`
+	UsableHosts      int64       `json:"usable_hosts"`
+	SubnetMask       string      `json:"subnet_mask"`
+	WildcardMask     string      `json:"wildcard_mask"`
+	BinarySubnetMask string      `json:"binary_subnet_mask"`
+	PrefixLength     int         `json:"prefix_length"`
+	BinaryOctets     string      `json:"binary_octets"`
+	Integer          uint32      `json:"integer"`
+	Hexadecimal      string      `json:"hexadecimal"`
+	LittleEndianHex  string      `json:"little_endian_hex"`
+	Octal            string      `json:"octal"`
+	NetworkBits      int         `json:"network_bits"`
+	HostBits         int         `json:"host_bits"`
+	SubnetOctet      int         `json:"subnet_octet"`
+	NumberOfSubnets  int64       `json:"number_of_subnets"`
+	Subnets          []subnetRow `json:"subnets"`
+}
+
+// newSubnetRow calculates one row of the subnet list for the network networkAddress/cidr.
+func newSubnetRow(networkAddress uint32, cidr int) subnetRow {
+	broadcastAddress := networkAddress | ^cidrToMaskInt(cidr)
+	row := subnetRow{
+		NetworkAddress:   intToIP(networkAddress),
+		BroadcastAddress: intToIP(broadcastAddress),
+		PrefixLength:     cidr,
+		TotalHosts:       int64(1) << (ipTotalBitCount - cidr),
+	}
+	if cidr <= maxNetworkBitsForUsefulHosts {
+		row.FirstUsable = intToIP(networkAddress + 1)
+		row.LastUsable = intToIP(broadcastAddress - 1)
+		row.UsableHosts = row.TotalHosts - 2
+	}
+	return row
+}
+
+// calculate builds the calcResult for ipv4/cidr. It works on its arguments only, so it is safe to
+// call for many inputs. The subnet list holds every /cidr inside the octet in which the mask ends
+// (the 1st to 4th octet for /1 to /30).
+func calculate(ipv4 string, cidr int) (*calcResult, error) {
+	ip, err := ipToInt(ipv4)
+	if err != nil {
+		return nil, err
+	}
+	if cidr < 0 || cidr > ipTotalBitCount {
+		return nil, errors.New("ERROR: Max network mask (bits) can be 32")
+	}
+	ipValidationMap, err := ipValidation(intToIP(ip))
+	if err != nil {
+		return nil, err
+	}
+	cidrToSubnetMaskMap, err := cidrToSubnetMask(cidr)
+	if err != nil {
+		return nil, err
+	}
+
+	networkAddress := ip & cidrToMaskInt(cidr)
+	current := newSubnetRow(networkAddress, cidr)
+
+	r := &calcResult{
+		SchemaVersion:    resultSchemaVersion,
+		IPAddress:        intToIP(ip),
+		NetworkAddress:   current.NetworkAddress,
+		FirstUsable:      current.FirstUsable,
+		LastUsable:       current.LastUsable,
+		BroadcastAddress: current.BroadcastAddress,
+		TotalHosts:       current.TotalHosts,
+		UsableHosts:      current.UsableHosts,
+		SubnetMask:       cidrToSubnetMaskMap["Subnet Mask"],
+		WildcardMask:     cidrToSubnetMaskMap["Wildcard Mask"],
+		BinarySubnetMask: cidrToSubnetMaskMap["Binary Subnet Mask"],
+		PrefixLength:     cidr,
+		BinaryOctets:     ipValidationMap["Binary Octets"],
+		Integer:          ip,
+		Hexadecimal:      ipValidationMap["Hexadecimal"],
+		LittleEndianHex:  ipValidationMap["Little-endian Hex (/proc)"],
+		Octal:            ipValidationMap["Octal"],
+		NetworkBits:      cidr,
+		HostBits:         ipTotalBitCount - cidr,
+		Subnets:          make([]subnetRow, 0),
+	}
+
+	if cidr >= 1 && cidr <= maxNetworkBitsForUsefulHosts {
+		octetBoundary := cidr / 8 * 8
+		r.SubnetOctet = octetBoundary/8 + 1
+		r.NumberOfSubnets = int64(1) << (cidr - octetBoundary)
+		base := ip & cidrToMaskInt(octetBoundary)
+		blockSize := uint32(1) << (ipTotalBitCount - cidr)
+		for i := int64(0); i < r.NumberOfSubnets; i++ {
+			row := newSubnetRow(base+uint32(i)*blockSize, cidr)
+			row.Current = row.NetworkAddress == r.NetworkAddress
+			r.Subnets = append(r.Subnets, row)
+		}
+	}
+	return r, nil
+}
+
+// jsonResult renders r in the JSON schema.
+func jsonResult(r *calcResult) (string, error) {
+	b, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("ERROR: %v", err)
+	}
+	return string(b), nil
+}
@@ -0,0 +1,164 @@
+/*
+File Name       : rules.go
+
+Purpose         : Renders networks as firewall configuration so they don't have to be retyped:
+                  iptables rules, an nftables set, a pf table, Cisco IOS standard and extended ACLs and
+                  a Juniper prefix-list. Every syntax is a text/template over ruleData, so a new syntax
+                  is one more entry in ruleTemplates, or a template file passed with -template.
+
+Usage           : sncalc rules [-syntax NAME] [-template FILE] [-name NAME] [-chain CHAIN] [-deny]
+                               [-f FILE] IP/CIDR ...
+                  Template fields: .Name .Chain .Permit and .Networks, a list with .Network .Prefix
+                  .CIDR .SubnetMask and .WildcardMask for every network.
+*/
+
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"text/template"
+)
+
+// ruleNetwork is one network as seen by the rule templates.
+type ruleNetwork struct {
+	Network      string
+	Prefix       int
+	CIDR         string
+	SubnetMask   string
+	WildcardMask string
+}
+
+// ruleData is the data every rule template is executed with.
+type ruleData struct {
+	Name     string
+	Chain    string
+	Permit   bool
+	Networks []ruleNetwork
+}
+
+// ruleTemplates holds the built-in syntaxes, selected with -syntax.
+var ruleTemplates = map[string]string{
+	"iptables": `{{range .Networks}}iptables -A {{$.Chain}} -s {{.CIDR}} -j {{if $.Permit}}ACCEPT{{else}}DROP{{end}}
+{{end}}`,
+
+	"nftables": `set {{.Name}} {
+	type ipv4_addr
+	flags interval
+	elements = { {{range $i, $n := .Networks}}{{if $i}}, {{end}}{{$n.CIDR}}{{end}} }
+}
+`,
+
+	"pf": `table <{{.Name}}> persist { {{range $i, $n := .Networks}}{{if $i}}, {{end}}{{$n.CIDR}}{{end}} }
+{{if .Permit}}pass{{else}}block{{end}} in quick from <{{.Name}}>
+`,
+
+	"cisco-std": `ip access-list standard {{.Name}}
+{{range .Networks}} {{if $.Permit}}permit{{else}}deny{{end}} {{.Network}} {{.WildcardMask}}
+{{end}}`,
+
+	"cisco-ext": `ip access-list extended {{.Name}}
+{{range .Networks}} {{if $.Permit}}permit{{else}}deny{{end}} ip {{.Network}} {{.WildcardMask}} any
+{{end}}`,
+
+	"juniper": `policy-options {
+    prefix-list {{.Name}} {
+{{range .Networks}}        {{.CIDR}};
+{{end}}    }
+}
+`,
+}
+
+// ruleNetworkFor calculates the template fields of ip/cidr. The masks come from cidrToSubnetMask so
+// they read exactly like the summary.
+func ruleNetworkFor(arg string) (ruleNetwork, error) {
+	ip, cidr, err := parseCidr(arg)
+	if err != nil {
+		return ruleNetwork{}, err
+	}
+	cidrToSubnetMaskMap, err := cidrToSubnetMask(cidr)
+	if err != nil {
+		return ruleNetwork{}, err
+	}
+	networkAddress := intToIP(ip & cidrToMaskInt(cidr))
+	return ruleNetwork{
+		Network:      networkAddress,
+		Prefix:       cidr,
+		CIDR:         fmt.Sprintf("%v/%v", networkAddress, cidr),
+		SubnetMask:   cidrToSubnetMaskMap["Subnet Mask"],
+		WildcardMask: cidrToSubnetMaskMap["Wildcard Mask"],
+	}, nil
+}
+
+// ruleSyntaxNames lists the built-in syntaxes for error messages.
+func ruleSyntaxNames() string {
+	names := make([]string, 0, len(ruleTemplates))
+	for name := range ruleTemplates {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return strings.Join(names, ", ")
+}
+
+// rulesMode renders the given networks in the selected firewall syntax.
+func rulesMode(args []string) error {
+	fs := flag.NewFlagSet("rules", flag.ContinueOnError)
+	syntax := fs.String("syntax", "iptables", "built-in syntax: "+ruleSyntaxNames())
+	templateFile := fs.String("template", "", "render with this template file instead of a built-in syntax")
+	name := fs.String("name", "SNCALC", "set, table, ACL or prefix-list name")
+	chain := fs.String("chain", "INPUT", "iptables chain")
+	deny := fs.Bool("deny", false, "generate deny/drop rules instead of permit/accept")
+	listFile := fs.String("f", "", "read networks from this file (\"-\" for stdin)")
+	if err := parseModeFlags(fs, args); err != nil {
+		return err
+	}
+
+	items := fs.Args()
+	if *listFile != "" {
+		fileItems, err := readAddressList(*listFile)
+		if err != nil {
+			return err
+		}
+		items = append(items, fileItems...)
+	}
+	if len(items) == 0 {
+		return errors.New("ERROR: rules needs at least one IP/CIDR network.")
+	}
+
+	data := ruleData{Name: *name, Chain: *chain, Permit: !*deny}
+	for _, item := range items {
+		network, err := ruleNetworkFor(item)
+		if err != nil {
+			return err
+		}
+		data.Networks = append(data.Networks, network)
+	}
+
+	var text string
+	if *templateFile != "" {
+		b, err := os.ReadFile(*templateFile)
+		if err != nil {
+			return fmt.Errorf("ERROR: %v", err)
+		}
+		text = string(b)
+	} else {
+		var ok bool
+		text, ok = ruleTemplates[*syntax]
+		if !ok {
+			return fmt.Errorf("ERROR: Unknown syntax %q, expected one of: %v.", *syntax, ruleSyntaxNames())
+		}
+	}
+
+	tmpl, err := template.New("rules").Option("missingkey=error").Parse(text)
+	if err != nil {
+		return fmt.Errorf("ERROR: %v", err)
+	}
+	if err := tmpl.Execute(os.Stdout, data); err != nil {
+		return fmt.Errorf("ERROR: %v", err)
+	}
+	return nil
+}
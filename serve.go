@@ -0,0 +1,284 @@
+/*
+File Name       : serve.go
+
+Purpose         : HTTP JSON API so other tools can do subnet math without shelling out.
+                    GET /calculate?address=10.0.0.77/26              calcResult, as --output json
+                    GET /split?network=10.0.0.0/24&prefix=26         subnet rows of the new prefix
+                    GET /summarize?networks=10.0.0.0/24,10.0.1.0/24  smallest covering CIDR list
+                    GET /range?start=10.0.0.5&end=10.0.0.20          CIDR blocks covering the range
+                    GET /contains?network=10.0.0.0/24&address=10.0.0.7
+                  Lists are returned as subnet rows in the same schema as the rows of --output json.
+                  Invalid input is answered with 400 and {"error": "..."}. Handlers only use
+                  calculate and the other pure helpers, never the ipv4/cidr globals, so concurrent
+                  requests do not share state.
+
+Usage           : sncalc serve [-listen :8080]
+*/
+
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"math/bits"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// maxSplitSubnets bounds the size of a /split response.
+const maxSplitSubnets int64 = 65536
+
+// networkListResult is the response of the endpoints returning a list of networks.
+type networkListResult struct {
+	SchemaVersion int         `json:"schema_version"`
+	Networks      []subnetRow `json:"networks"`
+}
+
+// containsResult is the response of /contains.
+type containsResult struct {
+	SchemaVersion int    `json:"schema_version"`
+	Network       string `json:"network"`
+	Address       string `json:"address"`
+	Contains      bool   `json:"contains"`
+}
+
+// splitNetwork divides ip/cidr into subnets of newCidr.
+func splitNetwork(ip uint32, cidr int, newCidr int) ([]subnetRow, error) {
+	if newCidr < cidr || newCidr > ipTotalBitCount {
+		return nil, fmt.Errorf("ERROR: The new prefix must be between /%v and /32.", cidr)
+	}
+	count := int64(1) << (newCidr - cidr)
+	if count > maxSplitSubnets {
+		return nil, fmt.Errorf("ERROR: Splitting a /%v into /%v gives %v subnets, the limit is %v.", cidr, newCidr, count, maxSplitSubnets)
+	}
+	networkAddress := ip & cidrToMaskInt(cidr)
+	blockSize := int64(1) << (ipTotalBitCount - newCidr)
+	rows := make([]subnetRow, 0, count)
+	for i := int64(0); i < count; i++ {
+		rows = append(rows, newSubnetRow(networkAddress+uint32(i*blockSize), newCidr))
+	}
+	return rows, nil
+}
+
+// summarizeNetworks returns the smallest list of CIDR blocks covering exactly the given networks,
+// using the contiguous merge of aclmin.
+func summarizeNetworks(networks []string) ([]subnetRow, error) {
+	entries := make([]wildcardEntry, 0, len(networks))
+	for _, network := range networks {
+		e, err := parseAddressOrCidr(network)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, e)
+	}
+	merged := mergeWildcardEntries(removeNestedEntries(entries), true)
+	rows := make([]subnetRow, 0, len(merged))
+	for _, e := range merged {
+		rows = append(rows, newSubnetRow(e.address, ipTotalBitCount-bits.OnesCount32(e.wildcard)))
+	}
+	return rows, nil
+}
+
+// rangeToCidrs returns the CIDR blocks that cover start to end exactly.
+func rangeToCidrs(start uint32, end uint32) ([]subnetRow, error) {
+	if start > end {
+		return nil, fmt.Errorf("ERROR: Range start %v is after the end %v.", intToIP(start), intToIP(end))
+	}
+	rows := make([]subnetRow, 0)
+	for current := uint64(start); current <= uint64(end); {
+		// Largest block aligned at current that does not pass end.
+		hostBits := 0
+		for hostBits < ipTotalBitCount {
+			size := uint64(1) << (hostBits + 1)
+			if current%size != 0 || current+size-1 > uint64(end) {
+				break
+			}
+			hostBits++
+		}
+		rows = append(rows, newSubnetRow(uint32(current), ipTotalBitCount-hostBits))
+		current += uint64(1) << hostBits
+	}
+	return rows, nil
+}
+
+// writeJSON writes v with the given status code.
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	enc.Encode(v)
+}
+
+// apiHandler adapts a function returning a result or an input error to an http.HandlerFunc.
+// Errors are input validation errors and answered with 400.
+func apiHandler(fn func(r *http.Request) (interface{}, error)) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			writeJSON(w, http.StatusMethodNotAllowed, map[string]string{"error": "ERROR: Only GET is supported."})
+			return
+		}
+		result, err := fn(r)
+		if err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+			return
+		}
+		writeJSON(w, http.StatusOK, result)
+	}
+}
+
+// requiredParam returns a query parameter or an error naming it.
+func requiredParam(r *http.Request, name string) (string, error) {
+	value := r.URL.Query().Get(name)
+	if value == "" {
+		return "", fmt.Errorf("ERROR: Missing query parameter %q.", name)
+	}
+	return value, nil
+}
+
+// apiCalculate answers /calculate. Without a /CIDR part the address is treated as a /32.
+func apiCalculate(r *http.Request) (interface{}, error) {
+	address, err := requiredParam(r, "address")
+	if err != nil {
+		return nil, err
+	}
+	if !strings.Contains(address, "/") {
+		address += "/32"
+	}
+	ip, cidr, err := parseCidr(address)
+	if err != nil {
+		return nil, err
+	}
+	return calculate(intToIP(ip), cidr)
+}
+
+// apiSplit answers /split.
+func apiSplit(r *http.Request) (interface{}, error) {
+	network, err := requiredParam(r, "network")
+	if err != nil {
+		return nil, err
+	}
+	prefixParam, err := requiredParam(r, "prefix")
+	if err != nil {
+		return nil, err
+	}
+	ip, cidr, err := parseCidr(network)
+	if err != nil {
+		return nil, err
+	}
+	newCidr, err := strconv.Atoi(strings.TrimPrefix(prefixParam, "/"))
+	if err != nil {
+		return nil, fmt.Errorf("ERROR: %q is not a valid CIDR value.", prefixParam)
+	}
+	rows, err := splitNetwork(ip, cidr, newCidr)
+	if err != nil {
+		return nil, err
+	}
+	return networkListResult{SchemaVersion: resultSchemaVersion, Networks: rows}, nil
+}
+
+// apiSummarize answers /summarize; networks may be comma separated or repeated.
+func apiSummarize(r *http.Request) (interface{}, error) {
+	var networks []string
+	for _, value := range r.URL.Query()["networks"] {
+		for _, network := range strings.Split(value, ",") {
+			if network = strings.TrimSpace(network); network != "" {
+				networks = append(networks, network)
+			}
+		}
+	}
+	if len(networks) == 0 {
+		return nil, errors.New("ERROR: Missing query parameter \"networks\".")
+	}
+	rows, err := summarizeNetworks(networks)
+	if err != nil {
+		return nil, err
+	}
+	return networkListResult{SchemaVersion: resultSchemaVersion, Networks: rows}, nil
+}
+
+// apiRange answers /range.
+func apiRange(r *http.Request) (interface{}, error) {
+	startParam, err := requiredParam(r, "start")
+	if err != nil {
+		return nil, err
+	}
+	endParam, err := requiredParam(r, "end")
+	if err != nil {
+		return nil, err
+	}
+	start, err := ipToInt(startParam)
+	if err != nil {
+		return nil, err
+	}
+	end, err := ipToInt(endParam)
+	if err != nil {
+		return nil, err
+	}
+	rows, err := rangeToCidrs(start, end)
+	if err != nil {
+		return nil, err
+	}
+	return networkListResult{SchemaVersion: resultSchemaVersion, Networks: rows}, nil
+}
+
+// apiContains answers /contains.
+func apiContains(r *http.Request) (interface{}, error) {
+	network, err := requiredParam(r, "network")
+	if err != nil {
+		return nil, err
+	}
+	addressParam, err := requiredParam(r, "address")
+	if err != nil {
+		return nil, err
+	}
+	ip, cidr, err := parseCidr(network)
+	if err != nil {
+		return nil, err
+	}
+	address, err := ipToInt(addressParam)
+	if err != nil {
+		return nil, err
+	}
+	mask := cidrToMaskInt(cidr)
+	return containsResult{
+		SchemaVersion: resultSchemaVersion,
+		Network:       fmt.Sprintf("%v/%v", intToIP(ip&mask), cidr),
+		Address:       intToIP(address),
+		Contains:      address&mask == ip&mask,
+	}, nil
+}
+
+// newAPIMux registers the API endpoints.
+func newAPIMux() *http.ServeMux {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/calculate", apiHandler(apiCalculate))
+	mux.HandleFunc("/split", apiHandler(apiSplit))
+	mux.HandleFunc("/summarize", apiHandler(apiSummarize))
+	mux.HandleFunc("/range", apiHandler(apiRange))
+	mux.HandleFunc("/contains", apiHandler(apiContains))
+	return mux
+}
+
+// serveMode runs the API server until it fails.
+func serveMode(args []string) error {
+	fs := flag.NewFlagSet("serve", flag.ContinueOnError)
+	listen := fs.String("listen", ":8080", "address to listen on")
+	if err := parseModeFlags(fs, args); err != nil {
+		return err
+	}
+	server := &http.Server{
+		Addr:              *listen,
+		Handler:           newAPIMux(),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	fmt.Printf("sncalc API listening on %v\n", *listen)
+	if err := server.ListenAndServe(); err != nil {
+		return fmt.Errorf("ERROR: %v", err)
+	}
+	return nil
+}
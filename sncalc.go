@@ -28,9 +28,10 @@ import (
 	"fmt"
 	"strings"
 	"strconv"
-	"math"
 	"errors"
 	"os"
+	"flag"
+	"math/bits"
 )
 
 
@@ -53,71 +54,120 @@ var (
 	//inputSubnetIp string = "172.16.0.0"
 	inputSubnetCidr int = 24
 	//requiredHostAddressesPerSubnet int = 30
-	
-	metricMap = make(map[string]string, 0)
-    //metricMap = map[string]string{}
-    
-    subnetListSlice = make([]string, 0)
 )
 
 
+// Modes are selected with the first command line argument, e.g. "sncalc ip6arpa 2001:db8::/50".
+// Without a mode the summary for ipv4/cidr is printed.
+var modeMap = map[string]func(args []string) error{
+	"ip6arpa": ip6ArpaMode,
+	"hosts": hostsMode,
+	"arith": arithMode,
+	"acl": aclMode,
+	"aclmin": aclMinMode,
+	"rules": rulesMode,
+	"prefixlist": prefixListMode,
+	"quiz": quizMode,
+	"serve": serveMode,
+	"web": webMode,
+	"repl": replMode,
+	"tui":  tuiMode,
+	"enrich": enrichMode,
+	"grep": grepMode,
+	"aggregate": aggregateMode,
+	"anonymize": anonymizeMode,
+	"renumber": renumberMode,
+	"netmap": netmapMode,
+}
+
+
 func main() {
 
-	ipValidationMap, err := ipValidation(ipv4)
-	if err != nil {
-		fmt.Println(err)
-		os.Exit(1)
-	} else {
-		for k, v := range ipValidationMap {
-			metricMap[k] = v
+	if len(os.Args) > 1 {
+		if modeFunc, ok := modeMap[os.Args[1]]; ok {
+			if err := modeFunc(os.Args[2:]); err != nil {
+				if errors.Is(err, flag.ErrHelp) {
+					return
+				}
+				if errors.Is(err, errFlagsReported) {
+					os.Exit(2)
+				}
+				fmt.Fprintln(os.Stderr, err)
+				os.Exit(1)
+			}
+			return
 		}
 	}
-	
-	subnetListSlice = append(subnetListSlice, fmt.Sprintf("  %-20v %-40v %v", "Network Address", "Usable Host Range", "Broadcast Address"))
-	subnetListSlice = append(subnetListSlice, fmt.Sprintf("  %-20v %-40v %v", "---------------", "-----------------", "-----------------"))
-	
-	cidrToSubnetMaskMap, err := cidrToSubnetMask(cidr)
-	if err != nil {
-		fmt.Println(err)
+
+	// An optional IP or IP/CIDR argument replaces the default ipv4/cidr. The address may be given
+	// in any of the forms ipToInt accepts and is shown in dotted decimal.
+	output := flag.String("output", "text", "output format: text, "+outputFormats)
+	format := flag.String("format", "", "text/template, @file or built-in format name (cidr, gateway, range, hosts, ifconfig)")
+	rows := flag.Bool("rows", false, "apply --format to every row of the subnet list")
+	bitsView := flag.Bool("bits", false, "show the addresses and masks stacked in binary")
+	explain := flag.Bool("explain", false, "show the step by step derivation")
+	listFile := flag.String("f", "", "calculate every IP/CIDR line of this file (\"-\" for stdin)")
+	flag.Parse()
+
+	// Batch mode: -f, or lines piped into stdin without an IP argument. --explain and --bits work on
+	// a single network, so with them stdin is not read even when it is not a terminal (cron, CI).
+	if *listFile != "" || (flag.NArg() == 0 && !*explain && !*bitsView && stdinIsPiped()) {
+		if *explain || *bitsView || flag.NArg() > 0 {
+			fmt.Fprintln(os.Stderr, errors.New("ERROR: --explain, --bits and an IP argument work on a single network, not with -f."))
+			os.Exit(1)
+		}
+		if *listFile == "" {
+			*listFile = "-"
+		}
+		if err := runBatch(os.Stdout, os.Stderr, *listFile, *output, *format, *rows); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		return
+	}
+	if flag.NArg() > 1 {
+		fmt.Fprintln(os.Stderr, fmt.Errorf("ERROR: Expected one IP or IP/CIDR argument, got %q; options go before it.", flag.Args()))
 		os.Exit(1)
-	} else {
-		subnetMask := cidrToSubnetMaskMap["Subnet Mask"]
-		subnetCalcMap, err := subnetCalc(ipv4, cidr, subnetMask)
-		if err != nil {
-			fmt.Println(err)
+	}
+	if flag.NArg() > 0 {
+		if err := setSummaryInput(flag.Arg(0)); err != nil {
+			fmt.Fprintln(os.Stderr, err)
 			os.Exit(1)
-		} else {
-			for k, v := range subnetCalcMap {
-				metricMap[k] = v
-			}
 		}
-		
-		for k, v := range cidrToSubnetMaskMap {
-			metricMap[k] = v
+	}
+
+	if *explain {
+		if err := writeExplanation(os.Stdout, ipv4, cidr); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
 		}
+		return
 	}
-	
-	hostsPerSubnetCalcMap, err := hostsPerSubnetCalc()
+
+	if *bitsView {
+		if err := writeBitMap(os.Stdout, ipv4, cidr, stdoutIsTerminal()); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	result, err := calculate(ipv4, cidr)
 	if err != nil {
-		fmt.Println(err)
+		fmt.Fprintln(os.Stderr, err)
 		os.Exit(1)
+	}
+	if *format != "" {
+		err = writeFormatted(os.Stdout, *format, *rows, result)
+	} else if *output == "text" {
+		writeTextResult(os.Stdout, result)
 	} else {
-		for k, v := range hostsPerSubnetCalcMap {
-			metricMap[k] = v
-		}
+		err = writeResult(os.Stdout, *output, result)
 	}
-	
-	fmt.Printf("\n")
-	metricMapDisplay()
-	fmt.Printf("\n")
-	
-	fmt.Printf("\n")
-	fmt.Printf("Number of Subnets: %s\n", metricMap["Number of Subnets"])
-	fmt.Printf("%s", metricMap["Subnet List"])
-	for _, v := range subnetListSlice {
-		fmt.Printf("%v\n", v)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
 	}
-	fmt.Printf("\n")
 	
 	
 	
@@ -178,21 +228,39 @@ func main() {
 }
 
 
-func metricMapDisplay() {
-	fmt.Printf("%-40s: %s\n", "IP Address", metricMap["IP Address"])
-	fmt.Printf("%-40s: %s\n", "Network Address", metricMap["Network Address"])
-	fmt.Printf("%-40s: %s\n", "Usable Host IP Range", metricMap["Usable Host IP Range"])
-	fmt.Printf("%-40s: %s\n", "Broadcast Address", metricMap["Broadcast Address"])
-	fmt.Printf("%-40s: %s\n", "Total Hosts per Subnet", metricMap["Total Hosts per Subnet"])
-	fmt.Printf("%-40s: %s\n", "Usable Hosts per Subnet", metricMap["Usable Hosts per Subnet"])
-	fmt.Printf("%-40s: %s\n", "Subnet Mask", metricMap["Subnet Mask"])
-	fmt.Printf("%-40s: %s\n", "Wildcard Mask", metricMap["Wildcard Mask"])
-	fmt.Printf("%-40s: %s\n", "Binary Subnet Mask", metricMap["Binary Subnet Mask"])
-	fmt.Printf("%-40s: %s\n", "CIDR Notation", metricMap["CIDR Notation"])
-	fmt.Printf("%-40s: %s\n", "Binary Octets", metricMap["Binary Octets"])
-	fmt.Printf("%-40s: %s\n", "Network Bits (total masked bits)", metricMap["Network Bits (total masked bits)"])
-	fmt.Printf("%-40s: %s\n", "Hosts Bits (unmasked bits)", metricMap["Hosts Bits (unmasked bits)"])
-	
+// errFlagsReported is returned for bad mode flags, which the flag package has already printed along
+// with the usage.
+var errFlagsReported = errors.New("ERROR: Invalid flags.")
+
+
+// parseModeFlags parses the flags of a mode. -h returns flag.ErrHelp, any other error
+// errFlagsReported, so main neither prints it twice nor treats help as a failure.
+func parseModeFlags(fs *flag.FlagSet, args []string) error {
+	err := fs.Parse(args)
+	if err == nil || errors.Is(err, flag.ErrHelp) {
+		return err
+	}
+	return errFlagsReported
+}
+
+
+// setSummaryInput replaces ipv4 and, when a /CIDR part is present, cidr.
+func setSummaryInput(arg string) error {
+	if strings.Contains(arg, "/") {
+		ip, inputCidr, err := parseCidr(arg)
+		if err != nil {
+			return err
+		}
+		ipv4 = intToIP(ip)
+		cidr = inputCidr
+		return nil
+	}
+	ip, err := ipToInt(arg)
+	if err != nil {
+		return err
+	}
+	ipv4 = intToIP(ip)
+	return nil
 }
 
 
@@ -222,272 +290,111 @@ func ipValidation(ipv4 string) (map[string]string, error) {
 	
 	ipValidationMap["Binary Octets"] = binaryOctets
 	
+	ipInt := uint32(octet1Decimal)<<24 | uint32(octet2Decimal)<<16 | uint32(octet3Decimal)<<8 | uint32(octet4Decimal)
+	ipValidationMap["Integer"] = fmt.Sprintf("%v", ipInt)
+	ipValidationMap["Hexadecimal"] = fmt.Sprintf("0x%08X", ipInt)
+	ipValidationMap["Little-endian Hex (/proc)"] = fmt.Sprintf("%08X", bits.ReverseBytes32(ipInt))
+	ipValidationMap["Octal"] = fmt.Sprintf("%#o", ipInt)
+	
 	return ipValidationMap, nil
 }
 
 
-func hostsPerSubnetCalc() (map[string]string, error) {
-	var hostsPerSubnetCalcMap = make(map[string]string, 0)
-	if cidr <= maxNetworkBitsForUsefulHosts {
-		unmaskedBits := ipTotalBitCount - cidr
-		hostsPerSubnet := math.Pow(2, float64(unmaskedBits))
-		hostsPerSubnetCalcMap["Total Hosts per Subnet"] = fmt.Sprintf("%v   (2^unmasked bits) => (2^%v)", int(hostsPerSubnet), unmaskedBits)
-		usableHostsPerSubnet := math.Pow(2, float64(unmaskedBits)) - 2
-		hostsPerSubnetCalcMap["Usable Hosts per Subnet"] = fmt.Sprintf("%v   (2^unmasked bits - 2) => (2^%v - 2)", int(usableHostsPerSubnet), unmaskedBits)
-	} else if cidr > maxNetworkBitsForUsefulHosts {
-		unmaskedBits := ipTotalBitCount - cidr
-		hostsPerSubnet := math.Pow(2, float64(unmaskedBits))
-		hostsPerSubnetCalcMap["Total Hosts per Subnet"] = fmt.Sprintf("%v   (2^unmasked bits) => (2^%v)", int(hostsPerSubnet), unmaskedBits)
-		hostsPerSubnetCalcMap["Usable Hosts per Subnet"] = fmt.Sprintf("%v", 0)
-	} else {
-		hostsPerSubnetCalcMap["Total Hosts per Subnet"] = fmt.Sprintf("%v", 0)
-		hostsPerSubnetCalcMap["Usable Hosts per Subnet"] = fmt.Sprintf("%v", 0)
+// ipToInt converts an IPv4 address into its 32-bit value. Besides dotted decimal it accepts the
+// other forms shown in the summary:
+//   3232235776   32-bit integer
+//   0xC0A80100   hex, most significant byte first
+//   le:0001A8C0  little-endian hex as found in /proc/net/route
+// Bare hex digits, and integers with leading zeros, are rejected rather than guessed at.
+func ipToInt(ipv4 string) (uint32, error) {
+	if !strings.Contains(ipv4, ".") {
+		return altIPToInt(ipv4)
 	}
-	
-	return hostsPerSubnetCalcMap, nil
+	ipSlice := strings.Split(ipv4, ".")
+	if len(ipSlice) != 4 {
+		return 0, fmt.Errorf("ERROR: %q is not a valid IPv4 address.", ipv4)
+	}
+	var n uint32
+	for _, octet := range ipSlice {
+		octetDecimal, err := strconv.Atoi(octet)
+		if err != nil || octetDecimal < 0 {
+			return 0, fmt.Errorf("ERROR: %q is not a valid IPv4 address.", ipv4)
+		}
+		if octetDecimal > maxOctetDecimal {
+			return 0, errors.New("ERROR: Max octet decimal value can be 255.")
+		}
+		n = n<<8 | uint32(octetDecimal)
+	}
+	return n, nil
 }
 
 
-func subnetCalc(ipv4 string, cidr int, subnetMask string) (map[string]string, error) {
-	var subnetCalcMap = make(map[string]string, 0)
-	ip1 := strings.Split(ipv4, ".")
-	
-	s := strings.Split(subnetMask, ".")
-	s1, _ := strconv.Atoi(s[0])
-	s2, _ := strconv.Atoi(s[1])
-	s3, _ := strconv.Atoi(s[2])
-	s4, _ := strconv.Atoi(s[3])
-	
-	if cidr >= 24 && cidr <= maxNetworkBitsForUsefulHosts {
-		maskedBits := cidr - 24
-		numberOfSubnets := math.Pow(2, float64(maskedBits))
-		subnetCalcMap["Number of Subnets"] = fmt.Sprintf("%v   (2^masked bits on 4th octet) => (2^%v)", numberOfSubnets, maskedBits)
-		networkPortion := fmt.Sprintf("%v.%v.%v", ip1[0], ip1[1], ip1[2])
-		subnetNbr := s4
-		octetPosition := 4
-		subnetCalcMap["Subnet List"] = fmt.Sprintf("All %v of the Possible /%v Networks for %v.%v.%v.* (valid subnets at 4th octet):\n", numberOfSubnets, cidr, ip1[0], ip1[1], ip1[2])
-		subnetListMap, err := subnetList(networkPortion, subnetNbr, octetPosition, ip1[3])
+// altIPToInt parses the integer and hex address forms accepted by ipToInt.
+func altIPToInt(s string) (uint32, error) {
+	lower := strings.ToLower(s)
+	if strings.HasPrefix(lower, "0x") {
+		n, err := strconv.ParseUint(lower[2:], 16, 32)
 		if err != nil {
-			fmt.Println(err)
-			os.Exit(1)
-		} else {
-			for k, v := range subnetListMap {
-				subnetCalcMap[k] = v
-			}
+			return 0, fmt.Errorf("ERROR: %q is not a valid hex IPv4 address.", s)
 		}
-		
-	} else if cidr >= 16 && cidr < 24 {
-		maskedBits := cidr - 16
-		numberOfSubnets := math.Pow(2, float64(maskedBits))
-		subnetCalcMap["Number of Subnets"] = fmt.Sprintf("%v   (2^masked bits on 3rd octet) => (2^%v)", numberOfSubnets, maskedBits)
-		networkPortion := fmt.Sprintf("%v.%v", ip1[0], ip1[1])
-		subnetNbr := s3
-		octetPosition := 3
-		subnetCalcMap["Subnet List"] = fmt.Sprintf("All %v of the Possible /%v Networks for %v.%v.*.* (valid subnets at 3rd octet):\n", numberOfSubnets, cidr, ip1[0], ip1[1])
-		subnetListMap, err := subnetList(networkPortion, subnetNbr, octetPosition, ip1[2])
-		if err != nil {
-			fmt.Println(err)
-			os.Exit(1)
-		} else {
-			for k, v := range subnetListMap {
-				subnetCalcMap[k] = v
-			}
-		}
-		
-	} else if cidr >= 8 && cidr < 16 {
-		maskedBits := cidr - 8
-		numberOfSubnets := math.Pow(2, float64(maskedBits))
-		subnetCalcMap["Number of Subnets"] = fmt.Sprintf("%v   (2^masked bits on 2nd octet) => (2^%v)", numberOfSubnets, maskedBits)
-		networkPortion := fmt.Sprintf("%v", ip1[0])
-		subnetNbr := s2
-		octetPosition := 2
-		subnetCalcMap["Subnet List"] = fmt.Sprintf("All %v of the Possible /%v Networks for %v.*.*.* (valid subnets at 2nd octet):\n", numberOfSubnets, cidr, ip1[0])
-		subnetListMap, err := subnetList(networkPortion, subnetNbr, octetPosition, ip1[1])
-		if err != nil {
-			fmt.Println(err)
-			os.Exit(1)
-		} else {
-			for k, v := range subnetListMap {
-				subnetCalcMap[k] = v
-			}
-		}
-		
-	} else if cidr >= 1 && cidr < 8 {
-		maskedBits := cidr - 0
-		numberOfSubnets := math.Pow(2, float64(maskedBits))
-		subnetCalcMap["Number of Subnets"] = fmt.Sprintf("%v   (2^masked bits on 1st octet) => (2^%v)", numberOfSubnets, maskedBits)
-		networkPortion := fmt.Sprintf("")
-		subnetNbr := s1
-		octetPosition := 1
-		subnetCalcMap["Subnet List"] = fmt.Sprintf("All %v of the Possible /%v Networks (valid subnets at 1st octet):\n", numberOfSubnets, cidr)
-		subnetListMap, err := subnetList(networkPortion, subnetNbr, octetPosition, ip1[0])
-		if err != nil {
-			fmt.Println(err)
-			os.Exit(1)
-		} else {
-			for k, v := range subnetListMap {
-				subnetCalcMap[k] = v
-			}
+		return uint32(n), nil
+	}
+	if strings.HasPrefix(lower, "le:") {
+		n, err := strconv.ParseUint(lower[3:], 16, 32)
+		if err != nil || len(lower) != 11 {
+			return 0, fmt.Errorf("ERROR: %q is not a valid little-endian hex IPv4 address, expected le: and 8 hex digits.", s)
 		}
-		
-	} else {
-		subnetCalcMap["Number of Subnets"] = fmt.Sprintf("%v", 0)
+		return bits.ReverseBytes32(uint32(n)), nil
 	}
-	
-	return subnetCalcMap, nil
+	// 8 hex digits are either big-endian or /proc little-endian hex; anything else that is not a
+	// plain integer is invalid.
+	if len(lower) == 8 && strings.Trim(lower, "0123456789abcdef") == "" && (strings.ContainsAny(lower, "abcdef") || lower[0] == '0') {
+		return 0, fmt.Errorf("ERROR: %q is ambiguous, write 0x%v for hex or le:%v for little-endian /proc hex.", s, s, s)
+	}
+	n, err := strconv.ParseUint(lower, 10, 32)
+	if err != nil || (len(lower) > 1 && lower[0] == '0') {
+		return 0, fmt.Errorf("ERROR: %q is not a valid IPv4 address.", s)
+	}
+	return uint32(n), nil
 }
 
 
-func subnetList(networkPortion string, subnetNbr int, octetPosition int, octetValue string) (map[string]string, error) {
-	var subnetListMap = make(map[string]string, 0)
-	
-	blockSize := 256 - subnetNbr
-	i := blockSize
-	octetValueInt , _ := strconv.Atoi(octetValue)
-	
-	if octetPosition == 4 {
-		nAddr := fmt.Sprintf("%v.%v", networkPortion, 0)
-		startIP := fmt.Sprintf("%v.%v", networkPortion, 1)
-		endIP := fmt.Sprintf("%v.%v", networkPortion, blockSize - 2)
-		usableHostIPRange := fmt.Sprintf("%v - %v", startIP, endIP)
-		broadcastAddress := fmt.Sprintf("%v.%v", networkPortion, blockSize - 1)
-		
-		if octetValueInt >= 0 && octetValueInt < i {
-			subnetListMap["Network Address"] = nAddr
-			subnetListMap["Usable Host IP Range"] = usableHostIPRange
-			subnetListMap["Broadcast Address"] = broadcastAddress
-			subnetListSlice = append(subnetListSlice, fmt.Sprintf("  %-20v %-40v %v [current]", nAddr, usableHostIPRange, broadcastAddress))
-			
-		} else {
-			subnetListSlice = append(subnetListSlice, fmt.Sprintf("  %-20v %-40v %v", nAddr, usableHostIPRange, broadcastAddress))
-		}
-		
-		for ; i <= subnetNbr; i = i + blockSize {
-			nAddr := fmt.Sprintf("%v.%v", networkPortion, i)
-			startIP := fmt.Sprintf("%v.%v", networkPortion, i + 1)
-			endIP := fmt.Sprintf("%v.%v", networkPortion, i + blockSize - 2)
-			usableHostIPRange := fmt.Sprintf("%v - %v", startIP, endIP)
-			broadcastAddress := fmt.Sprintf("%v.%v", networkPortion, i + blockSize - 1)
-			
-			if octetValueInt >= i && octetValueInt < i + blockSize {				
-				subnetListMap["Network Address"] = nAddr
-				subnetListMap["Usable Host IP Range"] = usableHostIPRange
-				subnetListMap["Broadcast Address"] = broadcastAddress
-				subnetListSlice = append(subnetListSlice, fmt.Sprintf("  %-20v %-40v %v [current]", nAddr, usableHostIPRange, broadcastAddress))
-				
-			} else {
-				subnetListSlice = append(subnetListSlice, fmt.Sprintf("  %-20v %-40v %v", nAddr, usableHostIPRange, broadcastAddress))
-			}
-		}
+// intToIP converts a 32-bit value into a dotted decimal IPv4 address.
+func intToIP(n uint32) string {
+	return fmt.Sprintf("%v.%v.%v.%v", n>>24, n>>16&0xff, n>>8&0xff, n&0xff)
+}
+
+
+// cidrToMaskInt returns the subnet mask for cidr as a 32-bit value, e.g. 26 => 0xffffffc0.
+func cidrToMaskInt(cidr int) uint32 {
+	if cidr <= 0 {
+		return 0
 	}
-	
-	if octetPosition == 3 {
-		nAddr := fmt.Sprintf("%v.%v.%v", networkPortion, 0, 0)
-		startIP := fmt.Sprintf("%v.%v.%v", networkPortion, 0, 1)
-		endIP := fmt.Sprintf("%v.%v.%v", networkPortion, i - 1, 254)
-		usableHostIPRange := fmt.Sprintf("%v - %v", startIP, endIP)
-		broadcastAddress := fmt.Sprintf("%v.%v.%v", networkPortion, i - 1, maxOctetDecimal)
-		
-		if octetValueInt >= 0 && octetValueInt < i {
-			subnetListMap["Network Address"] = nAddr
-			subnetListMap["Usable Host IP Range"] = usableHostIPRange
-			subnetListMap["Broadcast Address"] = broadcastAddress
-			subnetListSlice = append(subnetListSlice, fmt.Sprintf("  %-20v %-40v %v [current]", nAddr, usableHostIPRange, broadcastAddress))
-		} else {
-			subnetListSlice = append(subnetListSlice, fmt.Sprintf("  %-20v %-40v %v", nAddr, usableHostIPRange, broadcastAddress))
-		}
-		
-		for ; i <= subnetNbr; i = i + blockSize {
-			nAddr := fmt.Sprintf("%v.%v.%v", networkPortion, i, 0)
-			startIP := fmt.Sprintf("%v.%v.%v", networkPortion, i, 1)
-			endIP := fmt.Sprintf("%v.%v.%v", networkPortion, i + blockSize - 1, 254)
-			usableHostIPRange := fmt.Sprintf("%v - %v", startIP, endIP)
-			broadcastAddress := fmt.Sprintf("%v.%v.%v", networkPortion, i + blockSize - 1, maxOctetDecimal)
-			
-			if octetValueInt >= i && octetValueInt < i + blockSize {
-				subnetListMap["Network Address"] = nAddr
-				subnetListMap["Usable Host IP Range"] = usableHostIPRange
-				subnetListMap["Broadcast Address"] = broadcastAddress
-				subnetListSlice = append(subnetListSlice, fmt.Sprintf("  %-20v %-40v %v [current]", nAddr, usableHostIPRange, broadcastAddress))
-			} else {
-				subnetListSlice = append(subnetListSlice, fmt.Sprintf("  %-20v %-40v %v", nAddr, usableHostIPRange, broadcastAddress))
-			}
-		}
+	return ^uint32(0) << (ipTotalBitCount - cidr)
+}
+
+
+// parseCidr splits "10.8.4.0/22" into the address and the prefix length.
+func parseCidr(s string) (uint32, int, error) {
+	ipPart, cidrPart, found := strings.Cut(s, "/")
+	if !found {
+		return 0, 0, fmt.Errorf("ERROR: %q is missing the /CIDR part.", s)
 	}
-	
-	if octetPosition == 2 {
-		nAddr := fmt.Sprintf("%v.%v.%v.%v", networkPortion, 0, 0, 0)
-		startIP := fmt.Sprintf("%v.%v.%v.%v", networkPortion, 0, 0, 1)
-		endIP := fmt.Sprintf("%v.%v.%v.%v", networkPortion, i - 1, maxOctetDecimal, 254)
-		usableHostIPRange := fmt.Sprintf("%v - %v", startIP, endIP)
-		broadcastAddress := fmt.Sprintf("%v.%v.%v.%v", networkPortion, i - 1, maxOctetDecimal, maxOctetDecimal)
-		
-		if octetValueInt >= 0 && octetValueInt < i {
-			subnetListMap["Network Address"] = nAddr
-			subnetListMap["Usable Host IP Range"] = usableHostIPRange
-			subnetListMap["Broadcast Address"] = broadcastAddress
-			subnetListSlice = append(subnetListSlice, fmt.Sprintf("  %-20v %-40v %v [current]", nAddr, usableHostIPRange, broadcastAddress))
-		} else {
-			subnetListSlice = append(subnetListSlice, fmt.Sprintf("  %-20v %-40v %v", nAddr, usableHostIPRange, broadcastAddress))
-		}
-		
-		for ; i <= subnetNbr; i = i + blockSize {
-			nAddr := fmt.Sprintf("%v.%v.%v.%v", networkPortion, i, 0, 0)
-			startIP := fmt.Sprintf("%v.%v.%v.%v", networkPortion, i, 0, 1)
-			endIP := fmt.Sprintf("%v.%v.%v.%v", networkPortion, i + blockSize - 1, maxOctetDecimal, 254)
-			usableHostIPRange := fmt.Sprintf("%v - %v", startIP, endIP)
-			broadcastAddress := fmt.Sprintf("%v.%v.%v.%v", networkPortion, i + blockSize - 1, maxOctetDecimal, maxOctetDecimal)
-			
-			if octetValueInt >= i && octetValueInt < i + blockSize {
-				subnetListMap["Network Address"] = nAddr
-				subnetListMap["Usable Host IP Range"] = usableHostIPRange
-				subnetListMap["Broadcast Address"] = broadcastAddress
-				subnetListSlice = append(subnetListSlice, fmt.Sprintf("  %-20v %-40v %v [current]", nAddr, usableHostIPRange, broadcastAddress))
-			} else {
-				subnetListSlice = append(subnetListSlice, fmt.Sprintf("  %-20v %-40v %v", nAddr, usableHostIPRange, broadcastAddress))
-			}
-		}
+	ip, err := ipToInt(ipPart)
+	if err != nil {
+		return 0, 0, err
 	}
-	
-	if octetPosition == 1 {
-		nAddr := fmt.Sprintf("%v.%v.%v.%v", 0, 0, 0, 0)
-		startIP := fmt.Sprintf("%v.%v.%v.%v", 0, 0, 0, 1)
-		endIP := fmt.Sprintf("%v.%v.%v.%v", blockSize - 1, maxOctetDecimal, maxOctetDecimal, 254)
-		usableHostIPRange := fmt.Sprintf("%v - %v", startIP, endIP)
-		broadcastAddress := fmt.Sprintf("%v.%v.%v.%v", blockSize - 1, maxOctetDecimal, maxOctetDecimal, maxOctetDecimal)
-		
-		if octetValueInt >= 0 && octetValueInt < i {
-			subnetListMap["Network Address"] = nAddr
-			subnetListMap["Usable Host IP Range"] = usableHostIPRange
-			subnetListMap["Broadcast Address"] = broadcastAddress
-			subnetListSlice = append(subnetListSlice, fmt.Sprintf("  %-20v %-40v %v [current]", nAddr, usableHostIPRange, broadcastAddress))
-		} else {
-			subnetListSlice = append(subnetListSlice, fmt.Sprintf("  %-20v %-40v %v", nAddr, usableHostIPRange, broadcastAddress))
-		}
-		
-		for ; i <= subnetNbr; i = i + blockSize {
-			nAddr := fmt.Sprintf("%v.%v.%v.%v", i, 0, 0, 0)
-			startIP := fmt.Sprintf("%v.%v.%v.%v", i, 0, 0, 1)
-			endIP := fmt.Sprintf("%v.%v.%v.%v", i + blockSize - 1, maxOctetDecimal, maxOctetDecimal, 254)
-			usableHostIPRange := fmt.Sprintf("%v - %v", startIP, endIP)
-			broadcastAddress := fmt.Sprintf("%v.%v.%v.%v", i + blockSize - 1, maxOctetDecimal, maxOctetDecimal, maxOctetDecimal)
-			
-			if octetValueInt >= i && octetValueInt < i + blockSize {
-				subnetListMap["Network Address"] = nAddr
-				subnetListMap["Usable Host IP Range"] = usableHostIPRange
-				subnetListMap["Broadcast Address"] = broadcastAddress
-				subnetListSlice = append(subnetListSlice, fmt.Sprintf("  %-20v %-40v %v [current]", nAddr, usableHostIPRange, broadcastAddress))
-			} else {
-				subnetListSlice = append(subnetListSlice, fmt.Sprintf("  %-20v %-40v %v", nAddr, usableHostIPRange, broadcastAddress))
-			}
-		}
+	cidr, err := strconv.Atoi(cidrPart)
+	if err != nil || cidr < 0 {
+		return 0, 0, fmt.Errorf("ERROR: %q is not a valid CIDR value.", cidrPart)
 	}
-	
-	return subnetListMap, nil
+	if cidr > ipTotalBitCount {
+		return 0, 0, errors.New("ERROR: Max network mask (bits) can be 32")
+	}
+	return ip, cidr, nil
 }
 
 
-
 func cidrToSubnetMask(cidr int) (map[string]string, error) {
 	var cidrToSubnetMaskMap = make(map[string]string, 0)
 
@@ -0,0 +1,20 @@
+//go:build !linux
+
+/*
+File Name       : term_other.go
+
+Purpose         : Fallback for platforms without the termios support of term_linux.go. The
+                  interactive modes read plain lines instead of using raw mode.
+*/
+
+package main
+
+import "errors"
+
+var errNoRawMode = errors.New("ERROR: Raw terminal mode is not supported on this platform.")
+
+func isTerminal(fd int) bool { return false }
+
+func makeRaw(fd int) (func(), error) { return nil, errNoRawMode }
+
+func terminalSize(fd int) (int, int, error) { return 0, 0, errNoRawMode }
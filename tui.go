@@ -0,0 +1,348 @@
+/*
+File Name       : tui.go
+
+Purpose         : Full-screen browser for large splits, where the flat subnet list of main() scrolls
+                  off the screen. The summary of the current network is shown on top, its subnets in a
+                  scrollable list below. Rows are calculated on demand from their index, so even a /8
+                  split into /30s needs no list in memory.
+
+Keys            : up/down j/k, PgUp/PgDn, g/G     move in the list
+                  enter or right arrow            drill into the selected subnet
+                  backspace or left arrow         back to the parent (see the breadcrumb)
+                  + / -                           longer / shorter prefix length for the list
+                  /                               search the rows for text, or jump to the subnet
+                                                  containing an address
+                  n                               next row matching the last search
+                  q or Ctrl-C                     quit
+
+Usage           : sncalc tui [-split N] IP/CIDR
+*/
+
+package main
+
+import (
+	"bufio"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// tuiLevel is one entry of the breadcrumb: a network and how its subnet list is split.
+type tuiLevel struct {
+	network  uint32
+	cidr     int
+	splitLen int
+	selected int64
+	top      int64
+}
+
+// rowCount returns the number of subnets in the list of the level.
+func (l *tuiLevel) rowCount() int64 {
+	return int64(1) << (l.splitLen - l.cidr)
+}
+
+// row returns the network address of the ith subnet.
+func (l *tuiLevel) row(i int64) uint32 {
+	return l.network + uint32(i<<(ipTotalBitCount-l.splitLen))
+}
+
+// defaultSplitLen splits at the next octet boundary, but not into networks without usable hosts
+// unless the network itself is that small.
+func defaultSplitLen(cidr int) int {
+	splitLen := (cidr/8 + 1) * 8
+	if splitLen > maxNetworkBitsForUsefulHosts {
+		splitLen = maxNetworkBitsForUsefulHosts
+	}
+	if splitLen <= cidr {
+		splitLen = ipTotalBitCount
+	}
+	return splitLen
+}
+
+// tuiState is the browser: the breadcrumb of levels, the last one being on screen.
+type tuiState struct {
+	levels     []*tuiLevel
+	message    string
+	lastSearch string
+}
+
+// tuiSearchLimit bounds the rows a text search looks at, as a large split has millions of them.
+const tuiSearchLimit = 1 << 20
+
+func (t *tuiState) level() *tuiLevel {
+	return t.levels[len(t.levels)-1]
+}
+
+// move changes the selection by delta rows and keeps it visible.
+func (t *tuiState) move(delta int64, visibleRows int) {
+	l := t.level()
+	l.selected += delta
+	if l.selected < 0 {
+		l.selected = 0
+	}
+	if l.selected >= l.rowCount() {
+		l.selected = l.rowCount() - 1
+	}
+	if l.selected < l.top {
+		l.top = l.selected
+	}
+	if l.selected >= l.top+int64(visibleRows) {
+		l.top = l.selected - int64(visibleRows) + 1
+	}
+}
+
+// drill makes the selected subnet the current network.
+func (t *tuiState) drill() {
+	l := t.level()
+	if l.splitLen == l.cidr {
+		t.message = "A single network, nothing to drill into."
+		return
+	}
+	t.levels = append(t.levels, &tuiLevel{network: l.row(l.selected), cidr: l.splitLen, splitLen: defaultSplitLen(l.splitLen)})
+}
+
+// back returns to the parent network.
+func (t *tuiState) back() {
+	if len(t.levels) > 1 {
+		t.levels = t.levels[:len(t.levels)-1]
+	}
+}
+
+// resplit changes the prefix length of the list, keeping the selected address in view.
+func (t *tuiState) resplit(delta int, visibleRows int) {
+	l := t.level()
+	splitLen := l.splitLen + delta
+	if splitLen < l.cidr || splitLen > ipTotalBitCount {
+		return
+	}
+	address := l.row(l.selected)
+	l.splitLen = splitLen
+	l.selected, l.top = 0, 0
+	t.move(int64((address-l.network)>>(ipTotalBitCount-splitLen)), visibleRows)
+}
+
+// rowText returns the ith row of the subnet list as shown on screen, without highlighting.
+func (l *tuiLevel) rowText(i int64) string {
+	row := newSubnetRow(l.row(i), l.splitLen)
+	usable := ""
+	if row.UsableHosts > 0 {
+		usable = row.FirstUsable + " - " + row.LastUsable
+	}
+	return fmt.Sprintf("  %-20v %-40v %v", fmt.Sprintf("%v/%v", row.NetworkAddress, row.PrefixLength), usable, row.BroadcastAddress)
+}
+
+// search selects the subnet containing text if it is a dotted address, otherwise the next row after the
+// selection whose text contains it, wrapping around at the end of the list.
+func (t *tuiState) search(text string, visibleRows int) {
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return
+	}
+	t.lastSearch = text
+	l := t.level()
+	if ip, err := ipToInt(text); err == nil && strings.Count(text, ".") == 3 {
+		if ip&cidrToMaskInt(l.cidr) != l.network {
+			t.message = fmt.Sprintf("%v is not inside %v/%v.", intToIP(ip), intToIP(l.network), l.cidr)
+			return
+		}
+		t.move(int64((ip-l.network)>>(ipTotalBitCount-l.splitLen))-l.selected, visibleRows)
+		return
+	}
+	limit := l.rowCount()
+	if limit > tuiSearchLimit {
+		limit = tuiSearchLimit
+	}
+	for n := int64(1); n <= limit; n++ {
+		i := (l.selected + n) % l.rowCount()
+		if strings.Contains(l.rowText(i), text) {
+			t.move(i-l.selected, visibleRows)
+			return
+		}
+	}
+	t.message = fmt.Sprintf("%q not found in the next %v rows.", text, limit)
+}
+
+// render draws the whole screen.
+func (t *tuiState) render(w *bufio.Writer, rows int, prompt string) {
+	l := t.level()
+	r, _ := calculate(intToIP(l.network), l.cidr)
+
+	lines := make([]string, 0, rows)
+	crumbs := make([]string, 0, len(t.levels))
+	for _, level := range t.levels {
+		crumbs = append(crumbs, fmt.Sprintf("%v/%v", intToIP(level.network), level.cidr))
+	}
+	lines = append(lines, "\033[1m"+strings.Join(crumbs, " > ")+"\033[0m", "")
+
+	usableHostIPRange := ""
+	if r.UsableHosts > 0 {
+		usableHostIPRange = r.FirstUsable + " - " + r.LastUsable
+	}
+	for _, field := range [][2]string{
+		{"Network Address", r.NetworkAddress},
+		{"Usable Host IP Range", usableHostIPRange},
+		{"Broadcast Address", r.BroadcastAddress},
+		{"Subnet Mask", r.SubnetMask + "   (wildcard " + r.WildcardMask + ")"},
+		{"Usable Hosts per Subnet", fmt.Sprintf("%v", r.UsableHosts)},
+	} {
+		lines = append(lines, fmt.Sprintf("  %-26s: %s", field[0], field[1]))
+	}
+	lines = append(lines, "", fmt.Sprintf("\033[1m%v subnets of /%v\033[0m   (+/- change length, enter drill in, backspace back, / search, n next, q quit)", l.rowCount(), l.splitLen))
+	lines = append(lines, fmt.Sprintf("  %-20v %-40v %v", "Network Address", "Usable Host Range", "Broadcast Address"))
+
+	visibleRows := rows - len(lines) - 1
+	for i := l.top; i < l.top+int64(visibleRows) && i < l.rowCount(); i++ {
+		line := l.rowText(i)
+		if i == l.selected {
+			line = "\033[7m" + line + "\033[0m"
+		}
+		lines = append(lines, line)
+	}
+	for len(lines) < rows-1 {
+		lines = append(lines, "")
+	}
+
+	status := t.message
+	if prompt != "" {
+		status = prompt
+	}
+	lines = append(lines, status)
+
+	w.WriteString("\033[H")
+	for i, line := range lines {
+		if i > 0 {
+			w.WriteString("\r\n")
+		}
+		w.WriteString(line)
+		w.WriteString("\033[K")
+	}
+	w.Flush()
+}
+
+// tuiVisibleRows is the number of list rows on a screen of the given height; it matches the
+// layout of render (header and summary use 10 lines, the status line one).
+func tuiVisibleRows(rows int) int {
+	if rows-11 < 1 {
+		return 1
+	}
+	return rows - 11
+}
+
+// tuiMode runs the browser until q or Ctrl-C.
+func tuiMode(args []string) error {
+	fs := flag.NewFlagSet("tui", flag.ContinueOnError)
+	split := fs.Int("split", 0, "prefix length of the subnet list (0 = next octet boundary)")
+	if err := parseModeFlags(fs, args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		return errors.New("ERROR: tui needs exactly one IP/CIDR argument.")
+	}
+	ip, cidr, err := parseCidr(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	splitLen := defaultSplitLen(cidr)
+	if *split != 0 {
+		if *split < cidr || *split > ipTotalBitCount {
+			return fmt.Errorf("ERROR: -split must be between %v and 32.", cidr)
+		}
+		splitLen = *split
+	}
+	if !isTerminal(int(os.Stdin.Fd())) || !isTerminal(int(os.Stdout.Fd())) {
+		return errors.New("ERROR: tui needs a terminal.")
+	}
+
+	restore, err := makeRaw(int(os.Stdin.Fd()))
+	if err != nil {
+		return fmt.Errorf("ERROR: %v", err)
+	}
+	w := bufio.NewWriter(os.Stdout)
+	w.WriteString("\033[?1049h\033[?25l")
+	defer func() {
+		w.WriteString("\033[?25h\033[?1049l")
+		w.Flush()
+		restore()
+	}()
+
+	in := bufio.NewReader(os.Stdin)
+	t := &tuiState{levels: []*tuiLevel{{network: ip & cidrToMaskInt(cidr), cidr: cidr, splitLen: splitLen}}}
+	for {
+		_, rows, err := terminalSize(int(os.Stdout.Fd()))
+		if err != nil || rows < 12 {
+			rows = 24
+		}
+		visibleRows := tuiVisibleRows(rows)
+		t.render(w, rows, "")
+		t.message = ""
+
+		b, err := in.ReadByte()
+		if err != nil {
+			return nil
+		}
+		switch b {
+		case 'q', 3:
+			return nil
+		case 'j':
+			t.move(1, visibleRows)
+		case 'k':
+			t.move(-1, visibleRows)
+		case 'g':
+			t.move(-t.level().rowCount(), visibleRows)
+		case 'G':
+			t.move(t.level().rowCount(), visibleRows)
+		case '\r', '\n':
+			t.drill()
+		case 127, 8:
+			t.back()
+		case '+':
+			t.resplit(1, visibleRows)
+		case '-':
+			t.resplit(-1, visibleRows)
+		case '/':
+			text := ""
+			for {
+				t.render(w, rows, "Search (text or address): "+text)
+				c, err := in.ReadByte()
+				if err != nil || c == 3 || c == 27 {
+					break
+				}
+				if c == '\r' || c == '\n' {
+					t.search(text, visibleRows)
+					break
+				}
+				if (c == 127 || c == 8) && len(text) > 0 {
+					text = text[:len(text)-1]
+				} else if c >= ' ' && c < 127 {
+					text += string(c)
+				}
+			}
+		case 'n':
+			t.search(t.lastSearch, visibleRows)
+		case 27:
+			if c, _ := in.ReadByte(); c != '[' {
+				break
+			}
+			c, _ := in.ReadByte()
+			switch c {
+			case 'A':
+				t.move(-1, visibleRows)
+			case 'B':
+				t.move(1, visibleRows)
+			case 'C':
+				t.drill()
+			case 'D':
+				t.back()
+			case '5', '6':
+				in.ReadByte()
+				if c == '5' {
+					t.move(-int64(visibleRows), visibleRows)
+				} else {
+					t.move(int64(visibleRows), visibleRows)
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,55 @@
+/*
+File Name       : web.go
+
+Purpose         : Browser front end for people who prefer not to use a terminal. Serves
+                  web/index.html, embedded into the binary, together with the JSON API of serve.go
+                  under /api/. The page has no external references, so it works fully offline on a
+                  jump host.
+
+Usage           : sncalc web [-listen 127.0.0.1:8080]
+*/
+
+package main
+
+import (
+	_ "embed"
+	"flag"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+//go:embed web/index.html
+var webIndexHTML []byte
+
+// webIndex serves the calculator page.
+func webIndex(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != "/" {
+		http.NotFound(w, r)
+		return
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Write(webIndexHTML)
+}
+
+// webMode serves the page and the API until it fails.
+func webMode(args []string) error {
+	fs := flag.NewFlagSet("web", flag.ContinueOnError)
+	listen := fs.String("listen", "127.0.0.1:8080", "address to listen on")
+	if err := parseModeFlags(fs, args); err != nil {
+		return err
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", webIndex)
+	mux.Handle("/api/", http.StripPrefix("/api", newAPIMux()))
+	server := &http.Server{
+		Addr:              *listen,
+		Handler:           mux,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	fmt.Printf("sncalc web calculator on http://%v/\n", *listen)
+	if err := server.ListenAndServe(); err != nil {
+		return fmt.Errorf("ERROR: %v", err)
+	}
+	return nil
+}